@@ -0,0 +1,269 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
+	"github.com/google/uuid"
+)
+
+// handlerDeleteMe deactivates the caller's account and schedules it for
+// deletion once the grace period is over. Deactivation signs the user out
+// everywhere and hides their profile; logging in again before the deadline
+// reactivates the account and cancels the deletion.
+func (cfg *apiConfig) handlerDeleteMe(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Password string `json:"password"`
+	}
+	userID := auth.UserIDFromContext(r.Context())
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	throttleKeys := []throttle.Key{throttle.AccountKey(dbUser.Email), throttle.IPKey(cfg.clientIP(r))}
+	wait, err := cfg.loginLimiter.Check(r.Context(), throttleKeys...)
+	if err != nil {
+		respondWithError(w, 500, "Error checking login attempts")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+	hash, err := cfg.dbQueries.GetUserPassword(r.Context(), dbUser.Email)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	if auth.CheckPasswordHash(hash, rBody.Password) != nil {
+		err = cfg.loginLimiter.Fail(r.Context(), throttleKeys...)
+		if err != nil {
+			log.Printf("Error recording failed password check: %v", err)
+		}
+		respondWithError(w, 403, "Password is incorrect")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	deleteAfter, err := qtx.DeactivateUser(r.Context(), database.DeactivateUserParams{
+		ID:           userID,
+		GraceSeconds: cfg.deletionGracePeriod.Seconds(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	err = qtx.RevokeUserRefreshTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete account")
+		return
+	}
+	cfg.tokenVersions.Invalidate(userID)
+	respondWithJSON(w, 202, struct {
+		DeleteAfter time.Time `json:"delete_after"`
+	}{
+		DeleteAfter: deleteAfter.Time,
+	})
+}
+
+// watchAccountDeletions deletes accounts whose grace period has ended,
+// checking every interval until ctx is done. Everything the user owns goes
+// with the users row through ON DELETE CASCADE.
+func (cfg *apiConfig) watchAccountDeletions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := cfg.dbQueries.DeleteExpiredUsers(ctx)
+			if err != nil {
+				log.Printf("Error deleting expired accounts: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d accounts past their grace period", deleted)
+			}
+		}
+	}
+}
+
+type exportedChirp struct {
+	ID               uuid.UUID     `json:"id"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	Body             string        `json:"body"`
+	InReplyTo        uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf          uuid.NullUUID `json:"quote_of"`
+	EditedAt         *time.Time    `json:"edited_at"`
+	DeletedAt        *time.Time    `json:"deleted_at"`
+	ModerationStatus string        `json:"moderation_status"`
+}
+
+type exportedInteraction struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedSession struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// handlerExportMe returns a ZIP archive of everything Chirpy stores about
+// the caller, one JSON file per kind of data. Chirpy keeps no uploaded
+// media; the avatar is a link and is exported with the profile.
+func (cfg *apiConfig) handlerExportMe(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	acct, err := cfg.accountFor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	rawChirps, err := cfg.dbQueries.ExportChirps(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	chirps := []exportedChirp{}
+	for _, c := range rawChirps {
+		chirps = append(chirps, exportedChirp{
+			ID:               c.ID,
+			CreatedAt:        c.CreatedAt,
+			UpdatedAt:        c.UpdatedAt,
+			Body:             c.Body,
+			InReplyTo:        c.InReplyTo,
+			QuoteOf:          c.QuoteOf,
+			EditedAt:         nullTimePtr(c.EditedAt),
+			DeletedAt:        nullTimePtr(c.DeletedAt),
+			ModerationStatus: c.ModerationStatus,
+		})
+	}
+	rawLikes, err := cfg.dbQueries.ExportLikes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	likes := []exportedInteraction{}
+	for _, l := range rawLikes {
+		likes = append(likes, exportedInteraction{ChirpID: l.ChirpID, CreatedAt: l.CreatedAt})
+	}
+	rawRechirps, err := cfg.dbQueries.ExportRechirps(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	rechirps := []exportedInteraction{}
+	for _, rc := range rawRechirps {
+		rechirps = append(rechirps, exportedInteraction{ChirpID: rc.ChirpID, CreatedAt: rc.CreatedAt})
+	}
+	rawFollows, err := cfg.dbQueries.ExportFollows(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	follows := struct {
+		Following []follow `json:"following"`
+		Followers []follow `json:"followers"`
+	}{Following: []follow{}, Followers: []follow{}}
+	for _, f := range rawFollows {
+		if f.FollowerID == userID {
+			follows.Following = append(follows.Following, follow{UserID: f.FolloweeID, FollowedAt: f.CreatedAt})
+		} else {
+			follows.Followers = append(follows.Followers, follow{UserID: f.FollowerID, FollowedAt: f.CreatedAt})
+		}
+	}
+	families, err := cfg.dbQueries.ExportTokenFamilies(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	sessions := []exportedSession{}
+	for _, f := range families {
+		sessions = append(sessions, exportedSession{
+			ID:         f.ID,
+			CreatedAt:  f.CreatedAt,
+			LastUsedAt: f.LastUsedAt,
+			RevokedAt:  nullTimePtr(f.RevokedAt),
+			UserAgent:  f.UserAgent,
+			IPAddress:  f.IpAddress,
+		})
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range []struct {
+		name string
+		data any
+	}{
+		{"profile.json", acct},
+		{"chirps.json", chirps},
+		{"likes.json", likes},
+		{"rechirps.json", rechirps},
+		{"follows.json", follows},
+		{"sessions.json", sessions},
+	} {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			respondWithError(w, 500, "Couldn't export account")
+			return
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.data)
+		if err != nil {
+			respondWithError(w, 500, "Couldn't export account")
+			return
+		}
+	}
+	err = archive.Close()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't export account")
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.zip"`, time.Now().Format("2006-01-02")))
+	w.WriteHeader(200)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+type blockedUser struct {
+	UserID    uuid.UUID `json:"user_id"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type mutedUser struct {
+	UserID  uuid.UUID `json:"user_id"`
+	MutedAt time.Time `json:"muted_at"`
+}
+
+// chirpFilter decides which chirps a viewer sees based on blocks and mutes.
+// Chirps by users who blocked the viewer are hidden everywhere, direct links
+// included. Chirps by users the viewer blocked or muted are only left out of
+// listings, so a link to one still opens.
+type chirpFilter struct {
+	viewerID  uuid.UUID
+	blockedBy map[uuid.UUID]bool
+	filtered  map[uuid.UUID]bool
+}
+
+// chirpFilterFor loads the blocks and mutes that apply to viewerID.
+// Anonymous viewers get an empty filter.
+func (cfg *apiConfig) chirpFilterFor(ctx context.Context, viewerID uuid.UUID) (chirpFilter, error) {
+	f := chirpFilter{
+		viewerID:  viewerID,
+		blockedBy: map[uuid.UUID]bool{},
+		filtered:  map[uuid.UUID]bool{},
+	}
+	if viewerID == uuid.Nil {
+		return f, nil
+	}
+	blockerIDs, err := cfg.dbQueries.ListBlockerIDs(ctx, viewerID)
+	if err != nil {
+		return chirpFilter{}, err
+	}
+	for _, id := range blockerIDs {
+		f.blockedBy[id] = true
+	}
+	filteredIDs, err := cfg.dbQueries.ListFilteredUserIDs(ctx, viewerID)
+	if err != nil {
+		return chirpFilter{}, err
+	}
+	for _, id := range filteredIDs {
+		f.filtered[id] = true
+	}
+	return f, nil
+}
+
+// canSee reports whether the viewer may see chirps by authorID at all.
+func (f chirpFilter) canSee(authorID uuid.UUID) bool {
+	return !f.blockedBy[authorID]
+}
+
+// lists reports whether chirps by authorID belong in the viewer's listings.
+func (f chirpFilter) lists(authorID uuid.UUID) bool {
+	return f.canSee(authorID) && !f.filtered[authorID]
+}
+
+// seeable drops the chirps whose authors blocked the viewer, keeping the
+// order.
+func (f chirpFilter) seeable(rawChirps []database.Chirp) []database.Chirp {
+	kept := []database.Chirp{}
+	for _, rawChirp := range rawChirps {
+		if f.canSee(rawChirp.UserID) {
+			kept = append(kept, rawChirp)
+		}
+	}
+	return kept
+}
+
+// listed drops the chirps that don't belong in the viewer's listings,
+// keeping the order.
+func (f chirpFilter) listed(rawChirps []database.Chirp) []database.Chirp {
+	kept := []database.Chirp{}
+	for _, rawChirp := range rawChirps {
+		if f.lists(rawChirp.UserID) {
+			kept = append(kept, rawChirp)
+		}
+	}
+	return kept
+}
+
+// visibleChirp loads a single chirp for viewerID. It reports
+// sql.ErrNoRows, as if the chirp didn't exist, when moderation, a block or
+// the author deactivating their account hides it from the viewer.
+func (cfg *apiConfig) visibleChirp(ctx context.Context, chirpID, viewerID uuid.UUID, seeHidden bool) (database.Chirp, error) {
+	rawChirp, err := cfg.dbQueries.GetActiveChirpByID(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !canViewChirp(rawChirp, viewerID, seeHidden) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	filter, err := cfg.chirpFilterFor(ctx, viewerID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !filter.canSee(rawChirp.UserID) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return rawChirp, nil
+}
+
+// handlerBlockUser blocks the user and removes any follows between the two
+// accounts in either direction.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	blockedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if blockedID == userID {
+		respondWithError(w, 400, "You can't block yourself")
+		return
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), blockedID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't block user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	err = qtx.BlockUser(r.Context(), database.BlockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't block user")
+		return
+	}
+	err = qtx.RemoveFollowsBetween(r.Context(), database.RemoveFollowsBetweenParams{
+		UserA: userID,
+		UserB: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't block user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't block user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	blockedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	err = cfg.dbQueries.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unblock user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQueries.ListBlocks(r.Context(), database.ListBlocksParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get blocked users")
+		return
+	}
+	rows = trimPage(w, r, page, rows, func(row database.ListBlocksRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.UserID
+	})
+	blocks := []blockedUser{}
+	for _, row := range rows {
+		blocks = append(blocks, blockedUser{UserID: row.UserID, BlockedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, blocks)
+}
+
+// handlerMuteUser hides the user's chirps from the caller's listings. The
+// muted user isn't told and can still follow and interact with the caller.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	mutedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if mutedID == userID {
+		respondWithError(w, 400, "You can't mute yourself")
+		return
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), mutedID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	err = cfg.dbQueries.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't mute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	mutedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	err = cfg.dbQueries.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unmute user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQueries.ListMutes(r.Context(), database.ListMutesParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get muted users")
+		return
+	}
+	rows = trimPage(w, r, page, rows, func(row database.ListMutesRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.UserID
+	})
+	mutes := []mutedUser{}
+	for _, row := range rows {
+		mutes = append(mutes, mutedUser{UserID: row.UserID, MutedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, mutes)
+}
@@ -1,44 +1,56 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"slices"
-	"strings"
+	"net/url"
 	"time"
 
 	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/chirptext"
 	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/moderation"
 	"github.com/google/uuid"
 )
 
 type chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Body          string        `json:"body"`
+	UserID        uuid.UUID     `json:"user_id"`
+	InReplyTo     uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf       uuid.NullUUID `json:"quote_of"`
+	QuotedChirp   *chirp        `json:"quoted_chirp,omitempty"`
+	Deleted       bool          `json:"deleted,omitempty"`
+	Edited        bool          `json:"edited"`
+	ReplyCount    int64         `json:"reply_count"`
+	LikeCount     int64         `json:"like_count"`
+	RechirpCount  int64         `json:"rechirp_count"`
+	QuoteCount    int64         `json:"quote_count"`
+	LikedByMe     *bool         `json:"liked_by_me,omitempty"`
+	RechirpedByMe *bool         `json:"rechirped_by_me,omitempty"`
+	RechirpedBy   *rechirpInfo  `json:"rechirped_by,omitempty"`
+	Mentions      []mention     `json:"mentions"`
+	// Moderation details are only shown to the chirp's author.
+	ModerationStatus string   `json:"moderation_status,omitempty"`
+	ModerationRules  []string `json:"moderation_rules,omitempty"`
 }
 
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		Body string `json:"body"`
-	}
-	headers := r.Header
-	token, err := auth.GetBearerToken(headers)
-	if err != nil {
-		respondWithError(w, 500, "Error getting bearer token")
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
-	if err != nil {
-		respondWithError(w, 401, "Error validating JWT: "+err.Error())
-		return
+		Body      string        `json:"body"`
+		InReplyTo uuid.NullUUID `json:"in_reply_to"`
+		QuoteOf   uuid.NullUUID `json:"quote_of"`
 	}
+	userID := auth.UserIDFromContext(r.Context())
 	defer r.Body.Close()
 	decoder := json.NewDecoder(r.Body)
 	rBody := request{}
-	err = decoder.Decode(&rBody)
+	err := decoder.Decode(&rBody)
 	if err != nil {
 		respondWithError(w, 400, "Invalid request body")
 		return
@@ -47,102 +59,193 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 400, "Chirp is too long")
 		return
 	}
-	clean_chirp := cleanChirp(rBody.Body)
-	rawChirp, err := cfg.dbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   clean_chirp,
-		UserID: userID,
+	if cfg.requireVerifiedEmail {
+		author, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, 500, "Couldn't create chirp")
+			return
+		}
+		if !author.EmailVerifiedAt.Valid {
+			respondWithError(w, 403, "Verify your email address before chirping")
+			return
+		}
+	}
+	if rBody.InReplyTo.Valid {
+		parent, err := cfg.visibleChirp(r.Context(), rBody.InReplyTo.UUID, userID, false)
+		if err != nil || parent.DeletedAt.Valid {
+			respondWithError(w, 400, "Chirp being replied to not found")
+			return
+		}
+	}
+	if rBody.QuoteOf.Valid {
+		quoted, err := cfg.visibleChirp(r.Context(), rBody.QuoteOf.UUID, userID, false)
+		if err != nil || quoted.DeletedAt.Valid {
+			respondWithError(w, 400, "Quoted chirp not found")
+			return
+		}
+	}
+	result := cfg.moderator.Moderate(rBody.Body)
+	if result.Action == moderation.ActionReject {
+		respondWithError(w, 400, "Chirp violates content rules")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't create chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	rawChirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:             result.Text,
+		UserID:           userID,
+		InReplyTo:        rBody.InReplyTo,
+		QuoteOf:          rBody.QuoteOf,
+		ModerationStatus: moderationStatus(result),
+		ModerationRules:  result.Rules,
 	})
 	if err != nil {
 		respondWithError(w, 500, "Couldn't create chirp")
 		return
 	}
-	c := chirp{
-		ID:        rawChirp.ID,
-		CreatedAt: rawChirp.CreatedAt,
-		UpdatedAt: rawChirp.UpdatedAt,
-		Body:      rawChirp.Body,
-		UserID:    rawChirp.UserID,
+	err = saveChirpEntities(r.Context(), qtx, rawChirp.ID, result.Text)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't save hashtags and mentions")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't create chirp")
+		return
+	}
+	c, err := cfg.buildChirp(r.Context(), rawChirp, userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirp")
+		return
 	}
 	respondWithJSON(w, 201, c)
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("author_id")
-	if userID != "" {
-		userID, err := uuid.Parse(userID)
-		if err != nil {
-			respondWithError(w, 400, "Invalid author_id")
-			return
-		}
-		rawChirps, err := cfg.dbQueries.GetChirpsByUserID(r.Context(), userID)
-		if err != nil {
-			respondWithError(w, 500, "Couldn't get chirps")
-			return
-		}
-		chirps := []chirp{}
-		for _, rawChirp := range rawChirps {
-			c := chirp{
-				ID:        rawChirp.ID,
-				CreatedAt: rawChirp.CreatedAt,
-				UpdatedAt: rawChirp.UpdatedAt,
-				Body:      rawChirp.Body,
-				UserID:    rawChirp.UserID,
-			}
-			chirps = append(chirps, c)
-		}
-		respondWithJSON(w, 200, chirps)
+	query := r.URL.Query()
+	authorID, err := parseAuthorIDParam(query)
+	if err != nil {
+		respondWithError(w, 400, "Invalid author_id")
 		return
 	}
-	rawChirps, err := cfg.dbQueries.GetChirps(r.Context())
+	page, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	desc, err := parseSortParam(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	if authorID.Valid {
+		cfg.respondWithAuthorFeed(w, r, authorID.UUID, page, desc)
+		return
+	}
+	viewerID := auth.UserIDFromContext(r.Context())
+	seeHidden := hasPermission(r, auth.PermViewHiddenChirps)
+	var rawChirps []database.Chirp
+	if desc {
+		rawChirps, err = cfg.dbQueries.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			ViewerID:        nullViewerID(viewerID),
+			IncludeHidden:   seeHidden,
+			CursorCreatedAt: page.nullCreatedAt(),
+			CursorID:        page.nullID(),
+			PageLimit:       page.fetchLimit(),
+		})
+	} else {
+		rawChirps, err = cfg.dbQueries.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			ViewerID:        nullViewerID(viewerID),
+			IncludeHidden:   seeHidden,
+			CursorCreatedAt: page.nullCreatedAt(),
+			CursorID:        page.nullID(),
+			PageLimit:       page.fetchLimit(),
+		})
+	}
 	if err != nil {
 		respondWithError(w, 500, "Couldn't get chirps")
 		return
 	}
-	chirps := []chirp{}
-	for _, rawChirp := range rawChirps {
-		c := chirp{
-			ID:        rawChirp.ID,
-			CreatedAt: rawChirp.CreatedAt,
-			UpdatedAt: rawChirp.UpdatedAt,
-			Body:      rawChirp.Body,
-			UserID:    rawChirp.UserID,
-		}
-		chirps = append(chirps, c)
+	rawChirps = trimPage(w, r, page, rawChirps, chirpPosition)
+	chirps, err := cfg.buildChirps(r.Context(), rawChirps, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	respondWithJSON(w, 200, chirps)
+}
+
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQueries.ListTimeline(r.Context(), database.ListTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get timeline")
+		return
+	}
+	entries := []feedEntry{}
+	for _, row := range rows {
+		entries = append(entries, feedEntry(row))
+	}
+	entries = trimPage(w, r, page, entries, feedEntryPosition)
+	chirps, err := cfg.buildFeed(r.Context(), entries, userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
 	}
 	respondWithJSON(w, 200, chirps)
 }
 
 func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	rawChirp, err := cfg.dbQueries.GetChirpByID(r.Context(), uuid.MustParse(id))
+	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-	c := chirp{
-		ID:        rawChirp.ID,
-		CreatedAt: rawChirp.CreatedAt,
-		UpdatedAt: rawChirp.UpdatedAt,
-		Body:      rawChirp.Body,
-		UserID:    rawChirp.UserID,
+	viewerID := auth.UserIDFromContext(r.Context())
+	rawChirp, err := cfg.visibleChirp(r.Context(), id, viewerID, hasPermission(r, auth.PermViewHiddenChirps))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	c, err := cfg.buildChirp(r.Context(), rawChirp, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirp")
+		return
 	}
 	respondWithJSON(w, 200, c)
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	chirpID := r.PathValue("id")
-	token, err := auth.GetBearerToken(r.Header)
+	chirpID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		respondWithError(w, 401, "No token found in header")
+		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	userID := auth.UserIDFromContext(r.Context())
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
 	if err != nil {
-		respondWithError(w, 401, "Invalid token")
+		respondWithError(w, 500, "Couldn't delete chirp")
 		return
 	}
-	chirp, err := cfg.dbQueries.GetChirpByID(r.Context(), uuid.MustParse(chirpID))
-	if err != nil {
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
 		respondWithError(w, 404, "Chirp not found")
 		return
 	}
@@ -150,7 +253,22 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 403, "You are not allowed to delete this chirp")
 		return
 	}
-	err = cfg.dbQueries.DeleteChirp(r.Context(), uuid.MustParse(chirpID))
+	hasReplies, err := qtx.HasReplies(r.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete chirp")
+		return
+	}
+	// Replies keep pointing at a tombstone so the thread stays intact.
+	if hasReplies {
+		err = qtx.TombstoneChirp(r.Context(), chirp.ID)
+	} else {
+		err = deleteChirp(r.Context(), qtx, chirp)
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete chirp")
+		return
+	}
+	err = tx.Commit()
 	if err != nil {
 		respondWithError(w, 500, "Couldn't delete chirp")
 		return
@@ -158,15 +276,277 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(204)
 }
 
-func cleanChirp(chirp string) string {
-	dirty_words := []string{"kerfuffle", "sharbert", "fornax"}
-	clean_chirp := ""
-	for word := range strings.SplitSeq(chirp, " ") {
-		if slices.Contains(dirty_words, strings.ToLower(word)) {
-			clean_chirp += "**** "
-		} else {
-			clean_chirp += word + " "
+// deleteChirp deletes a chirp that has no replies, then any tombstoned
+// ancestors that it was the last reply to, so that a thread whose replies
+// are all gone doesn't leave empty tombstones behind.
+func deleteChirp(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	for {
+		// Locking the parent first serializes deletes of its replies, so
+		// whichever runs last sees that none are left.
+		var parent database.Chirp
+		hasParent := chirp.InReplyTo.Valid
+		if hasParent {
+			var err error
+			parent, err = qtx.GetChirpForUpdate(ctx, chirp.InReplyTo.UUID)
+			if errors.Is(err, sql.ErrNoRows) {
+				hasParent = false
+			} else if err != nil {
+				return err
+			}
+		}
+		err := qtx.DeleteChirp(ctx, chirp.ID)
+		if err != nil {
+			return err
+		}
+		if !hasParent || !parent.DeletedAt.Valid {
+			return nil
+		}
+		hasReplies, err := qtx.HasReplies(ctx, parent.ID)
+		if err != nil || hasReplies {
+			return err
+		}
+		chirp = parent
+	}
+}
+
+// saveChirpEntities indexes the hashtags and @mentions in body for the
+// chirp. It should run in the same transaction that writes the body. Tags
+// are dated by the chirp rather than the write, so re-indexing an edited
+// chirp doesn't push its tags back up the trending list.
+func saveChirpEntities(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, body string) error {
+	if tags := chirptext.Hashtags(body); len(tags) > 0 {
+		err := qtx.CreateHashtags(ctx, tags)
+		if err != nil {
+			return err
+		}
+		err = qtx.TagChirp(ctx, database.TagChirpParams{
+			ChirpID: chirpID,
+			Tags:    tags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if handles := chirptext.Mentions(body); len(handles) > 0 {
+		err := qtx.CreateMentions(ctx, database.CreateMentionsParams{
+			ChirpID:   chirpID,
+			Usernames: handles,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAuthorIDParam reads the optional author_id filter accepted by the
+// chirp listing endpoints.
+func parseAuthorIDParam(query url.Values) (uuid.NullUUID, error) {
+	rawAuthorID := query.Get("author_id")
+	if rawAuthorID == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(rawAuthorID)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func chirpFromDB(rawChirp database.Chirp) chirp {
+	return chirp{
+		ID:        rawChirp.ID,
+		CreatedAt: rawChirp.CreatedAt,
+		UpdatedAt: rawChirp.UpdatedAt,
+		Body:      rawChirp.Body,
+		UserID:    rawChirp.UserID,
+		InReplyTo: rawChirp.InReplyTo,
+		QuoteOf:   rawChirp.QuoteOf,
+		Deleted:   rawChirp.DeletedAt.Valid,
+		Edited:    rawChirp.EditedAt.Valid,
+		Mentions:  []mention{},
+	}
+}
+
+// buildChirps builds chirps for viewerID, which is uuid.Nil for anonymous
+// requests. Listings leave out blocked and muted users in their queries, so
+// that pages stay full; here only quotes are checked against blocks.
+func (cfg *apiConfig) buildChirps(ctx context.Context, rawChirps []database.Chirp, viewerID uuid.UUID) ([]chirp, error) {
+	filter, err := cfg.chirpFilterFor(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.assembleChirps(ctx, rawChirps, filter)
+}
+
+// assembleChirps converts database rows into API chirps, fills in the data
+// that lives outside the chirps table and embeds quoted chirps one level
+// deep, leaving out quotes of chirps the viewer may not see.
+func (cfg *apiConfig) assembleChirps(ctx context.Context, rawChirps []database.Chirp, filter chirpFilter) ([]chirp, error) {
+	viewerID := filter.viewerID
+	chirps, err := cfg.enrichChirps(ctx, rawChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	quotedIDs := []uuid.UUID{}
+	for _, c := range chirps {
+		if c.QuoteOf.Valid {
+			quotedIDs = append(quotedIDs, c.QuoteOf.UUID)
 		}
 	}
-	return strings.TrimSpace(clean_chirp)
+	if len(quotedIDs) == 0 {
+		return chirps, nil
+	}
+	rawQuoted, err := cfg.chirpsByIDs(ctx, quotedIDs)
+	if err != nil {
+		return nil, err
+	}
+	quoted, err := cfg.enrichChirps(ctx, filter.seeable(visibleChirps(rawQuoted, viewerID, false)), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	quotedByID := map[uuid.UUID]chirp{}
+	for _, c := range quoted {
+		quotedByID[c.ID] = c
+	}
+	for i := range chirps {
+		if q, ok := quotedByID[chirps[i].QuoteOf.UUID]; ok && chirps[i].QuoteOf.Valid {
+			chirps[i].QuotedChirp = &q
+		}
+	}
+	return chirps, nil
+}
+
+// enrichChirps converts database rows into API chirps and fills in the
+// counts and entities that live outside the chirps table, batching one query
+// per kind for the whole page.
+func (cfg *apiConfig) enrichChirps(ctx context.Context, rawChirps []database.Chirp, viewerID uuid.UUID) ([]chirp, error) {
+	chirps := []chirp{}
+	ids := []uuid.UUID{}
+	for _, rawChirp := range rawChirps {
+		chirps = append(chirps, chirpFromDB(rawChirp))
+		ids = append(ids, rawChirp.ID)
+	}
+	if len(ids) == 0 {
+		return chirps, nil
+	}
+	replyCounts, err := cfg.dbQueries.CountReplies(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	replies := map[uuid.UUID]int64{}
+	for _, row := range replyCounts {
+		replies[row.ChirpID] = row.ReplyCount
+	}
+	likeCounts, err := cfg.dbQueries.CountLikes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	likes := map[uuid.UUID]int64{}
+	for _, row := range likeCounts {
+		likes[row.ChirpID] = row.LikeCount
+	}
+	rechirpCounts, err := cfg.dbQueries.CountRechirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rechirps := map[uuid.UUID]int64{}
+	for _, row := range rechirpCounts {
+		rechirps[row.ChirpID] = row.RechirpCount
+	}
+	quoteCounts, err := cfg.dbQueries.CountQuotes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	quotes := map[uuid.UUID]int64{}
+	for _, row := range quoteCounts {
+		quotes[row.ChirpID] = row.QuoteCount
+	}
+	liked := map[uuid.UUID]bool{}
+	rechirped := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		likedIDs, err := cfg.dbQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+		rechirpedIDs, err := cfg.dbQueries.GetRechirpedChirpIDs(ctx, database.GetRechirpedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range rechirpedIDs {
+			rechirped[id] = true
+		}
+	}
+	mentionRows, err := cfg.dbQueries.ListMentionsForChirps(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentions := map[uuid.UUID][]mention{}
+	for _, row := range mentionRows {
+		mentions[row.ChirpID] = append(mentions[row.ChirpID], mention{
+			UserID:   row.UserID,
+			Username: row.Username,
+		})
+	}
+	for i := range chirps {
+		chirps[i].ReplyCount = replies[chirps[i].ID]
+		if m, ok := mentions[chirps[i].ID]; ok {
+			chirps[i].Mentions = m
+		}
+		chirps[i].LikeCount = likes[chirps[i].ID]
+		chirps[i].RechirpCount = rechirps[chirps[i].ID]
+		chirps[i].QuoteCount = quotes[chirps[i].ID]
+		if viewerID == chirps[i].UserID {
+			chirps[i].ModerationStatus = rawChirps[i].ModerationStatus
+			chirps[i].ModerationRules = rawChirps[i].ModerationRules
+		}
+		if viewerID != uuid.Nil {
+			likedByMe := liked[chirps[i].ID]
+			chirps[i].LikedByMe = &likedByMe
+			rechirpedByMe := rechirped[chirps[i].ID]
+			chirps[i].RechirpedByMe = &rechirpedByMe
+		}
+	}
+	return chirps, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, rawChirp database.Chirp, viewerID uuid.UUID) (chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, []database.Chirp{rawChirp}, viewerID)
+	if err != nil {
+		return chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// chirpsByIDs loads chirps by ID, keeping the order of ids and skipping any
+// that no longer exist.
+func (cfg *apiConfig) chirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	rawChirps, err := cfg.dbQueries.GetChirpsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uuid.UUID]database.Chirp{}
+	for _, rawChirp := range rawChirps {
+		byID[rawChirp.ID] = rawChirp
+	}
+	ordered := []database.Chirp{}
+	for _, id := range ids {
+		if rawChirp, ok := byID[id]; ok {
+			ordered = append(ordered, rawChirp)
+		}
+	}
+	return ordered, nil
+}
+
+func chirpPosition(c database.Chirp) (time.Time, uuid.UUID) {
+	return c.CreatedAt, c.ID
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	emailVerificationLifetime = 24 * time.Hour
+	emailVerificationCooldown = time.Minute
+)
+
+// queueEmailVerification sends a verification link for email on the mail
+// queue; a slow mail server shouldn't hold up signup.
+func (cfg *apiConfig) queueEmailVerification(r *http.Request, userID uuid.UUID, email string) {
+	err := cfg.mailQueue.Enqueue(func(ctx context.Context) {
+		err := cfg.sendEmailVerification(ctx, userID, email)
+		if err != nil {
+			log.Printf("Error sending email verification: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Error queueing email verification: %v", err)
+	}
+}
+
+// sendEmailVerification emails a link that, once followed, marks email as
+// verified for the user, replacing their current address if it differs.
+func (cfg *apiConfig) sendEmailVerification(ctx context.Context, userID uuid.UUID, email string) error {
+	token, err := auth.MakeEmailToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.dbQueries.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash:       auth.HashEmailToken(token),
+		UserID:          userID,
+		Email:           email,
+		LifetimeSeconds: emailVerificationLifetime.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+	link := cfg.baseURL + "/api/verify-email?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email for Chirpy",
+		Body: fmt.Sprintf("Confirm that this is your email address by opening this link within the next day:\n\n%s\n\n"+
+			"If you didn't sign up for Chirpy, you can ignore this email.\n", link),
+	})
+}
+
+var verifyEmailPage = template.Must(template.New("verify-email").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Verify your email for Chirpy</title></head>
+<body>
+<form method="post" action="/api/verify-email">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Verify my email address</button>
+</form>
+</body>
+</html>
+`))
+
+// handlerShowVerifyEmail is where the link in a verification email lands.
+// Mail scanners and link previews open links on their own, so it only checks
+// the token and asks the user to confirm; the form posts to
+// handlerVerifyEmail, which uses the token up.
+func (cfg *apiConfig) handlerShowVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	_, err := cfg.dbQueries.GetEmailVerificationToken(r.Context(), auth.HashEmailToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	verifyEmailPage.Execute(w, token)
+}
+
+// handlerVerifyEmail confirms an address using the token from a
+// verification email, sent as a JSON body by clients or as a form by the
+// page handlerShowVerifyEmail serves.
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := ""
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		token = r.PostFormValue("token")
+	} else {
+		type requestBody struct {
+			Token string `json:"token"`
+		}
+		defer r.Body.Close()
+		decoder := json.NewDecoder(r.Body)
+		rBody := requestBody{}
+		err := decoder.Decode(&rBody)
+		if err != nil {
+			respondWithError(w, 400, "Error decoding request body")
+			return
+		}
+		token = rBody.Token
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	stored, err := qtx.GetEmailVerificationTokenForUpdate(r.Context(), auth.HashEmailToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	dbUser, err := qtx.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    stored.UserID,
+		Email: stored.Email,
+	})
+	if isUniqueViolation(err, "users_email_key") {
+		respondWithError(w, 409, "Email already registered")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	err = qtx.UseEmailVerificationTokens(r.Context(), stored.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify email")
+		return
+	}
+	acct, err := cfg.accountFor(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get account")
+		return
+	}
+	respondWithJSON(w, 200, acct)
+}
+
+// handlerResendVerification sends another verification email: to the
+// pending new address if there is one, otherwise to the unverified current
+// address.
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't send verification email")
+		return
+	}
+	email, err := cfg.dbQueries.GetPendingEmail(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if dbUser.EmailVerifiedAt.Valid {
+			respondWithError(w, 400, "Email already verified")
+			return
+		}
+		email, err = dbUser.Email, nil
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't send verification email")
+		return
+	}
+	recent, err := cfg.dbQueries.HasRecentEmailVerificationToken(r.Context(), database.HasRecentEmailVerificationTokenParams{
+		UserID:          userID,
+		CooldownSeconds: emailVerificationCooldown.Seconds(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't send verification email")
+		return
+	}
+	if recent {
+		w.Header().Set("Retry-After", fmt.Sprint(int(emailVerificationCooldown.Seconds())))
+		respondWithError(w, 429, "A verification email was sent recently")
+		return
+	}
+	cfg.queueEmailVerification(r, userID, email)
+	w.WriteHeader(202)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+type follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if followeeID == userID {
+		respondWithError(w, 400, "You can't follow yourself")
+		return
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	blocked, err := cfg.dbQueries.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+		UserA: userID,
+		UserB: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't follow user")
+		return
+	}
+	if blocked {
+		respondWithError(w, 403, "You can't follow this user")
+		return
+	}
+	err = cfg.dbQueries.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't follow user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	followeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	err = cfg.dbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unfollow user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQueries.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get followers")
+		return
+	}
+	rows = trimPage(w, r, page, rows, func(row database.ListFollowersRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.UserID
+	})
+	follows := []follow{}
+	for _, row := range rows {
+		follows = append(follows, follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, follows)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rows, err := cfg.dbQueries.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get followed users")
+		return
+	}
+	rows = trimPage(w, r, page, rows, func(row database.ListFollowingRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.UserID
+	})
+	follows := []follow{}
+	for _, row := range rows {
+		follows = append(follows, follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, follows)
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,26 +27,111 @@ func CheckPasswordHash(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
-	return token.SignedString([]byte(tokenSecret))
+var (
+	ErrNoAuthHeader        = errors.New("no authorization header found")
+	ErrMalformedAuthHeader = errors.New("malformed authorization header")
+)
+
+// Claims are the claims carried by Chirpy access tokens. Role is the
+// user's role when the token was issued; a role change takes effect when the
+// user next gets a token. Scope is a space-separated list of scopes, as in
+// RFC 8693. Version is the user's token version at issue time; see
+// VersionCache.
+type Claims struct {
+	Role      Role   `json:"role"`
+	Scope     string `json:"scope,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	Version   int32  `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+// A TokenOption sets optional claims on a token minted by MakeJWT.
+type TokenOption func(*Claims)
+
+// WithSession ties the token to the login session it was issued for.
+func WithSession(sessionID uuid.UUID) TokenOption {
+	return func(c *Claims) {
+		c.SessionID = sessionID.String()
+	}
+}
+
+// WithVersion stamps the token with the user's current token version.
+func WithVersion(version int32) TokenOption {
+	return func(c *Claims) {
+		c.Version = version
+	}
+}
+
+func MakeJWT(userID uuid.UUID, role Role, keys *KeySet, expiresIn time.Duration, opts ...TokenOption) (string, error) {
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+		},
+	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
+	return keys.sign(claims)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	jwtToken, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
+// mfaAudience marks MFA challenge tokens, which prove a correct password
+// but must never be accepted as access tokens.
+const mfaAudience = "chirpy-mfa"
+
+// MakeMFAChallenge returns a token proving that userID has passed the
+// password step of a two-step login.
+func MakeMFAChallenge(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return keys.sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy",
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+		},
 	})
+}
+
+// ParseMFAChallenge validates a token from MakeMFAChallenge and returns its
+// claims. Callers must record the ID so the challenge is only used once.
+func ParseMFAChallenge(tokenString string, keys *KeySet) (*Claims, error) {
+	jwtToken, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc, jwt.WithValidMethods(keys.validMethods()), jwt.WithAudience(mfaAudience), jwt.WithExpirationRequired())
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
-	claims, ok := jwtToken.Claims.(*jwt.RegisteredClaims)
+	claims, ok := jwtToken.Claims.(*Claims)
+	if !ok || claims.ID == "" {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// ParseJWT validates an access token against keys and returns its claims.
+func ParseJWT(tokenString string, keys *KeySet) (*Claims, error) {
+	jwtToken, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.keyFunc, jwt.WithValidMethods(keys.validMethods()))
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := jwtToken.Claims.(*Claims)
 	if !ok {
-		return uuid.Nil, errors.New("invalid token claims")
+		return nil, errors.New("invalid token claims")
+	}
+	if slices.Contains(claims.Audience, mfaAudience) {
+		return nil, errors.New("MFA challenge tokens are not access tokens")
+	}
+	return claims, nil
+}
+
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, keys)
+	if err != nil {
+		return uuid.Nil, err
 	}
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
@@ -56,11 +143,11 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 func GetBearerToken(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
-		return "", errors.New("no authorization header found")
+		return "", ErrNoAuthHeader
 	}
 	authToken, ok := strings.CutPrefix(authHeader, "Bearer ")
 	if !ok {
-		return "", errors.New("malformed authorization header")
+		return "", ErrMalformedAuthHeader
 	}
 	return authToken, nil
 }
@@ -68,11 +155,11 @@ func GetBearerToken(headers http.Header) (string, error) {
 func GetAPIKey(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
-		return "", errors.New("no authorization header found")
+		return "", ErrNoAuthHeader
 	}
 	apiKey, ok := strings.CutPrefix(authHeader, "ApiKey ")
 	if !ok {
-		return "", errors.New("malformed authorization header")
+		return "", ErrMalformedAuthHeader
 	}
 	return apiKey, nil
 }
@@ -85,3 +172,22 @@ func MakeRefreshToken() (string, error) {
 	}
 	return hex.EncodeToString(randBytes), nil
 }
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token, which is what
+// gets stored, so a database leak doesn't expose usable tokens. Refresh
+// tokens are 256 random bits, so a fast unsalted hash is enough.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MakeEmailToken returns a random token for a single-use link sent by
+// email, such as a password reset. Only HashEmailToken(token) is stored.
+func MakeEmailToken() (string, error) {
+	return MakeRefreshToken()
+}
+
+// HashEmailToken hashes an email token the same way refresh tokens are.
+func HashEmailToken(token string) string {
+	return HashRefreshToken(token)
+}
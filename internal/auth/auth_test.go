@@ -35,13 +35,13 @@ func TestWrongHash(t *testing.T) {
 
 func TestMakeJWT(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "secret"
+	keys := NewHMACKeySet("secret")
 	expiresIn := 1 * time.Hour
-	token, err := MakeJWT(userID, tokenSecret, expiresIn)
+	token, err := MakeJWT(userID, RoleUser, keys, expiresIn)
 	if err != nil {
 		t.Fatalf("Error making JWT: %v", err)
 	}
-	parsedUserID, err := ValidateJWT(token, tokenSecret)
+	parsedUserID, err := ValidateJWT(token, keys)
 	if err != nil {
 		t.Fatalf("Error validating JWT: %v", err)
 	}
@@ -50,16 +50,35 @@ func TestMakeJWT(t *testing.T) {
 	}
 }
 
+func TestJWTRoleClaim(t *testing.T) {
+	userID := uuid.New()
+	keys := NewHMACKeySet("secret")
+	token, err := MakeJWT(userID, RoleModerator, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("Error making JWT: %v", err)
+	}
+	claims, err := ParseJWT(token, keys)
+	if err != nil {
+		t.Fatalf("Error parsing JWT: %v", err)
+	}
+	if claims.Role != RoleModerator {
+		t.Fatalf("Expected role %q, got %q", RoleModerator, claims.Role)
+	}
+	if claims.Subject != userID.String() {
+		t.Fatalf("Expected subject %v, got %v", userID, claims.Subject)
+	}
+}
+
 func TestWrongToken(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "secret"
+	keys := NewHMACKeySet("secret")
 	expiresIn := 1 * time.Hour
-	_, err := MakeJWT(userID, tokenSecret, expiresIn)
+	_, err := MakeJWT(userID, RoleUser, keys, expiresIn)
 	if err != nil {
 		t.Fatalf("Error making JWT: %v", err)
 	}
 	wrongToken := "wrong_token"
-	parsedUserID, err := ValidateJWT(wrongToken, tokenSecret)
+	parsedUserID, err := ValidateJWT(wrongToken, keys)
 	if err == nil {
 		t.Fatalf("Expected error for wrong token, got nil")
 	}
@@ -70,13 +89,13 @@ func TestWrongToken(t *testing.T) {
 
 func TestExpiredToken(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "secret"
+	keys := NewHMACKeySet("secret")
 	expiresIn := -1 * time.Hour
-	token, err := MakeJWT(userID, tokenSecret, expiresIn)
+	token, err := MakeJWT(userID, RoleUser, keys, expiresIn)
 	if err != nil {
 		t.Fatalf("Error making JWT: %v", err)
 	}
-	parsedUserID, err := ValidateJWT(token, tokenSecret)
+	parsedUserID, err := ValidateJWT(token, keys)
 	if err == nil {
 		t.Fatalf("Expected error for expired token, got nil")
 	}
@@ -107,3 +126,39 @@ func TestGetBearerTokenMissingHeader(t *testing.T) {
 		t.Fatalf("Expected error message %v, got %v", "no authorization header found", err.Error())
 	}
 }
+
+func TestHashRefreshToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Error making refresh token: %v", err)
+	}
+	hash := HashRefreshToken(token)
+	if hash == token || len(hash) != 64 {
+		t.Fatalf("Expected a 64 character hex hash, got %q", hash)
+	}
+	if HashRefreshToken(token) != hash {
+		t.Fatalf("Expected hashing to be deterministic")
+	}
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got := HashRefreshToken("hello"); got != expected {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestJWTUniqueID(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+	first, _ := MakeJWT(userID, RoleUser, keys, time.Hour)
+	second, _ := MakeJWT(userID, RoleUser, keys, time.Hour)
+	firstClaims, err := ParseJWT(first, keys)
+	if err != nil {
+		t.Fatalf("Error parsing JWT: %v", err)
+	}
+	secondClaims, err := ParseJWT(second, keys)
+	if err != nil {
+		t.Fatalf("Error parsing JWT: %v", err)
+	}
+	if firstClaims.ID == "" || firstClaims.ID == secondClaims.ID {
+		t.Fatalf("Expected distinct jti claims, got %q and %q", firstClaims.ID, secondClaims.ID)
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxCachedEntries bounds a ttlCache; past it, expired entries are swept.
+const maxCachedEntries = 10000
+
+type cachedValue[V any] struct {
+	value   V
+	fetched time.Time
+}
+
+// ttlCache remembers the result of lookup for each key for ttl.
+type ttlCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	lookup  func(ctx context.Context, key K) (V, error)
+	ttl     time.Duration
+	entries map[K]cachedValue[V]
+	now     func() time.Time
+}
+
+func newTTLCache[K comparable, V any](lookup func(ctx context.Context, key K) (V, error), ttl time.Duration) ttlCache[K, V] {
+	return ttlCache[K, V]{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: map[K]cachedValue[V]{},
+		now:     time.Now,
+	}
+}
+
+func (c *ttlCache[K, V]) get(ctx context.Context, key K) (V, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetched) < c.ttl {
+		return entry.value, nil
+	}
+	value, err := c.lookup(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedEntries {
+		c.sweep()
+	}
+	c.entries[key] = cachedValue[V]{value: value, fetched: c.now()}
+	return value, nil
+}
+
+func (c *ttlCache[K, V]) invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// sweep drops expired entries, or everything if none have expired. c.mu must
+// be held.
+func (c *ttlCache[K, V]) sweep() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.Sub(entry.fetched) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxCachedEntries {
+		clear(c.entries)
+	}
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is an asymmetric key used to sign access tokens. Keys are only
+// used between NotBefore and NotAfter; a zero time leaves that end open.
+type SigningKey struct {
+	ID        string
+	Alg       string
+	NotBefore time.Time
+	NotAfter  time.Time
+	private   crypto.Signer
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Alg == jwt.SigningMethodEdDSA.Alg() {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// verifies reports whether tokens signed with k are still accepted at now.
+// Keys are published and accepted before NotBefore so that verifiers already
+// have them when signing switches over.
+func (k *SigningKey) verifies(now time.Time) bool {
+	return k.NotAfter.IsZero() || now.Before(k.NotAfter)
+}
+
+func (k *SigningKey) signs(now time.Time) bool {
+	return k.verifies(now) && !now.Before(k.NotBefore)
+}
+
+// KeySet holds the keys used to sign and verify access tokens.
+//
+// Asymmetric keys are loaded from a directory of PEM files, one private key
+// per file, with the file name (minus .pem) as the key ID. Optional
+// Not-Before and Not-After PEM headers (RFC 3339) schedule a rotation: new
+// tokens are signed with the usable key that became active most recently,
+// and every key that hasn't passed its Not-After is published and accepted.
+// To rotate, drop in a new key with a future Not-Before and give the old one
+// a Not-After later than the new key's Not-Before plus the token lifetime.
+//
+// An HMAC secret can be set as well. It signs tokens only when there is no
+// usable asymmetric key, and HS256 tokens are accepted while it is set, so
+// a deployment can move to asymmetric keys without logging everyone out and
+// then drop the secret.
+type KeySet struct {
+	mu         sync.RWMutex
+	dir        string
+	keys       []*SigningKey
+	hmacSecret []byte
+	now        func() time.Time
+}
+
+// NewHMACKeySet returns a key set that signs and verifies with HS256 only.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{hmacSecret: []byte(secret), now: time.Now}
+}
+
+// LoadKeySet loads the keys in dir. hmacSecret may be empty.
+func LoadKeySet(dir, hmacSecret string) (*KeySet, error) {
+	ks := &KeySet{dir: dir, now: time.Now}
+	if hmacSecret != "" {
+		ks.hmacSecret = []byte(hmacSecret)
+	}
+	err := ks.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload re-reads the key directory.
+func (ks *KeySet) Reload() error {
+	if ks.dir == "" {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	keys := []*SigningKey{}
+	for _, path := range paths {
+		key, err := readSigningKey(path)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 && ks.hmacSecret == nil {
+		return fmt.Errorf("no signing keys in %s", ks.dir)
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	return nil
+}
+
+// Watch reloads the key directory every interval until ctx is done, so that
+// keys added or retired on disk are picked up without a restart.
+func (ks *KeySet) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := ks.Reload()
+			if err != nil {
+				log.Printf("Error reloading signing keys: %v", err)
+			}
+		}
+	}
+}
+
+func readSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key := &SigningKey{ID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	for header, field := range map[string]*time.Time{"Not-Before": &key.NotBefore, "Not-After": &key.NotAfter} {
+		if raw, ok := block.Headers[header]; ok {
+			*field, err = time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s header: %w", header, err)
+			}
+		}
+	}
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Alg = jwt.SigningMethodRS256.Alg()
+		key.private = k
+	case ed25519.PrivateKey:
+		key.Alg = jwt.SigningMethodEdDSA.Alg()
+		key.private = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// sign signs claims with the current signing key.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := ks.now()
+	var current *SigningKey
+	for _, key := range ks.keys {
+		if key.signs(now) && (current == nil || key.NotBefore.After(current.NotBefore)) {
+			current = key
+		}
+	}
+	if current == nil {
+		if ks.hmacSecret == nil {
+			return "", errors.New("no active signing key")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.hmacSecret)
+	}
+	token := jwt.NewWithClaims(current.method(), claims)
+	token.Header["kid"] = current.ID
+	return token.SignedString(current.private)
+}
+
+// keyFunc picks the verification key for a token from its kid header.
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		if ks.hmacSecret == nil {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return ks.hmacSecret, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	now := ks.now()
+	for _, key := range ks.keys {
+		if key.ID != kid {
+			continue
+		}
+		if !key.verifies(now) {
+			return nil, fmt.Errorf("key %s has been retired", kid)
+		}
+		if key.Alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %s is not an %s key", kid, token.Method.Alg())
+		}
+		return key.private.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// validMethods lists the algorithms this key set can verify, so tokens
+// can't pick a different one.
+func (ks *KeySet) validMethods() []string {
+	methods := []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+	if ks.hmacSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of every key that verifies tokens,
+// including keys scheduled to start signing later.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	now := ks.now()
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		if !key.verifies(now) {
+			continue
+		}
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Alg}
+		switch pub := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
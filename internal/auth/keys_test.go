@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writeKey(t *testing.T, dir, kid string, key any, headers map[string]string) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error marshalling key: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: headers, Bytes: der})
+	err = os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600)
+	if err != nil {
+		t.Fatalf("Error writing key: %v", err)
+	}
+}
+
+func kidOf(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("Error parsing token: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	writeKey(t, dir, "2026-01", edKey, map[string]string{
+		"Not-After": "2026-02-01T01:00:00Z",
+	})
+	writeKey(t, dir, "2026-02", rsaKey, map[string]string{
+		"Not-Before": "2026-02-01T00:00:00Z",
+	})
+	keys, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("Error loading keys: %v", err)
+	}
+	userID := uuid.New()
+
+	keys.now = func() time.Time { return time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC) }
+	oldToken, err := MakeJWT(userID, RoleUser, keys, 1000*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Error making JWT: %v", err)
+	}
+	if kid := kidOf(t, oldToken); kid != "2026-01" {
+		t.Fatalf("Expected kid 2026-01 before the rotation, got %q", kid)
+	}
+	if len(keys.JWKS().Keys) != 2 {
+		t.Fatalf("Expected the scheduled key to be published early")
+	}
+
+	keys.now = func() time.Time { return time.Date(2026, 2, 1, 0, 30, 0, 0, time.UTC) }
+	newToken, err := MakeJWT(userID, RoleUser, keys, 1000*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Error making JWT: %v", err)
+	}
+	if kid := kidOf(t, newToken); kid != "2026-02" {
+		t.Fatalf("Expected kid 2026-02 after the rotation, got %q", kid)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		parsedUserID, err := ValidateJWT(token, keys)
+		if err != nil || parsedUserID != userID {
+			t.Fatalf("Expected token to validate during the overlap: %v", err)
+		}
+	}
+
+	keys.now = func() time.Time { return time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC) }
+	_, err = ValidateJWT(oldToken, keys)
+	if err == nil {
+		t.Fatalf("Expected token from a retired key to be rejected")
+	}
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != "2026-02" || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].E != "AQAB" {
+		t.Fatalf("Unexpected JWKS %+v", jwks)
+	}
+}
+
+func TestHMACTokensRejectedWithoutSecret(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "main", edKey, nil)
+	keys, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("Error loading keys: %v", err)
+	}
+	forged, err := MakeJWT(uuid.New(), RoleAdmin, NewHMACKeySet("guessed"), time.Hour)
+	if err != nil {
+		t.Fatalf("Error making JWT: %v", err)
+	}
+	_, err = ValidateJWT(forged, keys)
+	if err == nil {
+		t.Fatalf("Expected HS256 token to be rejected")
+	}
+}
+
+func TestLoadKeySetEmptyDir(t *testing.T) {
+	_, err := LoadKeySet(t.TempDir(), "")
+	if err == nil {
+		t.Fatalf("Expected error for a directory without keys")
+	}
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const realm = "chirpy"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    uuid.UUID
+	Role      Role
+	Scopes    []string
+	TokenID   string
+	SessionID uuid.UUID
+}
+
+// Can reports whether the principal's role grants perm.
+func (p Principal) Can(perm Permission) bool {
+	return p.Role.Can(perm)
+}
+
+// HasScope reports whether the principal's token was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying p.
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by the middleware. ok is
+// false for anonymous requests.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// UserIDFromContext returns the authenticated user's ID, or uuid.Nil for
+// anonymous requests.
+func UserIDFromContext(ctx context.Context) uuid.UUID {
+	p, _ := PrincipalFromContext(ctx)
+	return p.UserID
+}
+
+// Authenticator validates access tokens and stores the resulting Principal
+// in the request context. Failures are reported as described in RFC 6750.
+type Authenticator struct {
+	keys     *KeySet
+	versions *VersionCache
+	sessions *SessionCache
+}
+
+// NewAuthenticator returns an Authenticator that verifies tokens with keys.
+// When versions is not nil, tokens older than the user's current token
+// version are rejected. When sessions is not nil, tokens issued to a session
+// that has since been logged out are rejected too.
+func NewAuthenticator(keys *KeySet, versions *VersionCache, sessions *SessionCache) *Authenticator {
+	return &Authenticator{keys: keys, versions: versions, sessions: sessions}
+}
+
+// Authenticate validates the bearer token on r and returns its principal.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	token, err := GetBearerToken(r.Header)
+	if err != nil {
+		return Principal{}, err
+	}
+	claims, err := ParseJWT(token, a.keys)
+	if err != nil {
+		return Principal{}, err
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return Principal{}, err
+	}
+	if a.versions != nil {
+		current, err := a.versions.Version(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Principal{}, ErrTokenRevoked
+		}
+		if err != nil {
+			return Principal{}, fmt.Errorf("%w: %w", ErrTokenCheckFailed, err)
+		}
+		if claims.Version < current {
+			return Principal{}, ErrTokenRevoked
+		}
+	}
+	p := Principal{
+		UserID:  userID,
+		Role:    claims.Role,
+		Scopes:  strings.Fields(claims.Scope),
+		TokenID: claims.ID,
+	}
+	if claims.SessionID != "" {
+		p.SessionID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return Principal{}, err
+		}
+		if a.sessions != nil {
+			revoked, err := a.sessions.Revoked(r.Context(), p.SessionID)
+			if err != nil {
+				return Principal{}, fmt.Errorf("%w: %w", ErrTokenCheckFailed, err)
+			}
+			if revoked {
+				return Principal{}, ErrTokenRevoked
+			}
+		}
+	}
+	return p, nil
+}
+
+// Required rejects requests without a valid access token.
+func (a *Authenticator) Required(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			challenge(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), p)))
+	})
+}
+
+// Optional lets anonymous requests through, but still rejects requests that
+// present a bad token rather than quietly treating them as anonymous.
+func (a *Authenticator) Optional(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoAuthHeader) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			challenge(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithPrincipal(r.Context(), p)))
+	})
+}
+
+// RequirePermission rejects requests whose principal's role does not grant
+// perm.
+func (a *Authenticator) RequirePermission(perm Permission, next http.HandlerFunc) http.Handler {
+	return a.Required(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := PrincipalFromContext(r.Context())
+		if !p.Can(perm) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="insufficient_scope"`, realm))
+			writeError(w, 403, "Forbidden")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// challenge writes the RFC 6750 response for a failed authentication: a bare
+// challenge when no credentials were sent, invalid_request for a malformed
+// header and invalid_token for a token that doesn't validate. A token that
+// couldn't be checked gets a 503 and no challenge, so clients don't throw
+// away credentials that may be fine.
+func challenge(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTokenCheckFailed):
+		log.Printf("Error authenticating request: %v", err)
+		writeError(w, 503, "Couldn't verify token")
+	case errors.Is(err, ErrNoAuthHeader):
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, realm))
+		writeError(w, 401, "No token found in header")
+	case errors.Is(err, ErrMalformedAuthHeader):
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="invalid_request"`, realm))
+		writeError(w, 400, "Malformed authorization header")
+	default:
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="invalid_token", error_description=%q`, realm, "The access token is invalid or expired"))
+		writeError(w, 401, "Invalid token")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
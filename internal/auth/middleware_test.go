@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func serve(h http.Handler, authHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequiredAuth(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	a := NewAuthenticator(keys, nil, nil)
+	userID := uuid.New()
+	var got Principal
+	h := a.Required(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+	})
+
+	rec := serve(h, "")
+	if rec.Code != 401 {
+		t.Fatalf("Expected 401 without a token, got %d", rec.Code)
+	}
+	if challenge := rec.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="chirpy"` {
+		t.Fatalf("Unexpected challenge %q", challenge)
+	}
+
+	rec = serve(h, "Bearer not-a-jwt")
+	if rec.Code != 401 {
+		t.Fatalf("Expected 401 for an invalid token, got %d", rec.Code)
+	}
+	if challenge := rec.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, `error="invalid_token"`) {
+		t.Fatalf("Expected invalid_token challenge, got %q", challenge)
+	}
+
+	rec = serve(h, "Basic abc")
+	if rec.Code != 400 {
+		t.Fatalf("Expected 400 for a malformed header, got %d", rec.Code)
+	}
+
+	sessionID := uuid.New()
+	token, err := MakeJWT(userID, RoleModerator, keys, time.Hour, WithSession(sessionID))
+	if err != nil {
+		t.Fatalf("Error making JWT: %v", err)
+	}
+	rec = serve(h, "Bearer "+token)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for a valid token, got %d", rec.Code)
+	}
+	if got.UserID != userID || got.Role != RoleModerator || got.SessionID != sessionID {
+		t.Fatalf("Unexpected principal %+v", got)
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	a := NewAuthenticator(keys, nil, nil)
+	called := false
+	h := a.Optional(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if UserIDFromContext(r.Context()) != uuid.Nil {
+			t.Errorf("Expected anonymous request")
+		}
+	})
+	rec := serve(h, "")
+	if rec.Code != 200 || !called {
+		t.Fatalf("Expected anonymous request to pass, got %d", rec.Code)
+	}
+	called = false
+	rec = serve(h, "Bearer not-a-jwt")
+	if rec.Code != 401 || called {
+		t.Fatalf("Expected 401 for an invalid token, got %d", rec.Code)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	a := NewAuthenticator(keys, nil, nil)
+	h := a.RequirePermission(PermViewMetrics, func(w http.ResponseWriter, r *http.Request) {})
+	userToken, _ := MakeJWT(uuid.New(), RoleUser, keys, time.Hour)
+	rec := serve(h, "Bearer "+userToken)
+	if rec.Code != 403 {
+		t.Fatalf("Expected 403 for a user, got %d", rec.Code)
+	}
+	if challenge := rec.Header().Get("WWW-Authenticate"); !strings.Contains(challenge, `error="insufficient_scope"`) {
+		t.Fatalf("Expected insufficient_scope challenge, got %q", challenge)
+	}
+	adminToken, _ := MakeJWT(uuid.New(), RoleAdmin, keys, time.Hour)
+	rec = serve(h, "Bearer "+adminToken)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for an admin, got %d", rec.Code)
+	}
+}
+
+func TestRevokedTokenVersion(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+	current := int32(0)
+	versions := NewVersionCache(func(ctx context.Context, id uuid.UUID) (int32, error) {
+		return current, nil
+	}, time.Minute)
+	a := NewAuthenticator(keys, versions, nil)
+	h := a.Required(func(w http.ResponseWriter, r *http.Request) {})
+
+	oldToken, _ := MakeJWT(userID, RoleUser, keys, time.Hour, WithVersion(0))
+	rec := serve(h, "Bearer "+oldToken)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for a current token, got %d", rec.Code)
+	}
+
+	current = 1
+	versions.Invalidate(userID)
+	rec = serve(h, "Bearer "+oldToken)
+	if rec.Code != 401 {
+		t.Fatalf("Expected 401 for a token from an older version, got %d", rec.Code)
+	}
+	newToken, _ := MakeJWT(userID, RoleUser, keys, time.Hour, WithVersion(1))
+	rec = serve(h, "Bearer "+newToken)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for a token from the new version, got %d", rec.Code)
+	}
+}
+
+func TestRevokedSession(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	sessionID := uuid.New()
+	revoked := false
+	sessions := NewSessionCache(func(ctx context.Context, id uuid.UUID) (bool, error) {
+		return revoked && id == sessionID, nil
+	}, time.Minute)
+	a := NewAuthenticator(keys, nil, sessions)
+	h := a.Required(func(w http.ResponseWriter, r *http.Request) {})
+
+	token, _ := MakeJWT(uuid.New(), RoleUser, keys, time.Hour, WithSession(sessionID))
+	rec := serve(h, "Bearer "+token)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for an active session, got %d", rec.Code)
+	}
+
+	revoked = true
+	sessions.Invalidate(sessionID)
+	rec = serve(h, "Bearer "+token)
+	if rec.Code != 401 {
+		t.Fatalf("Expected 401 for a revoked session, got %d", rec.Code)
+	}
+	other, _ := MakeJWT(uuid.New(), RoleUser, keys, time.Hour, WithSession(uuid.New()))
+	rec = serve(h, "Bearer "+other)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 for another session, got %d", rec.Code)
+	}
+}
+
+func TestTokenCheckFailure(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	var lookupErr error
+	versions := NewVersionCache(func(ctx context.Context, id uuid.UUID) (int32, error) {
+		return 0, lookupErr
+	}, time.Minute)
+	a := NewAuthenticator(keys, versions, nil)
+	h := a.Required(func(w http.ResponseWriter, r *http.Request) {})
+	token, _ := MakeJWT(uuid.New(), RoleUser, keys, time.Hour)
+
+	lookupErr = errors.New("connection refused")
+	rec := serve(h, "Bearer "+token)
+	if rec.Code != 503 {
+		t.Fatalf("Expected 503 when the token can't be checked, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != "" {
+		t.Fatalf("Expected no challenge when the token can't be checked, got %q", got)
+	}
+
+	lookupErr = sql.ErrNoRows
+	rec = serve(h, "Bearer "+token)
+	if rec.Code != 401 {
+		t.Fatalf("Expected 401 for a user that no longer exists, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import "fmt"
+
+// Role is the access level of an account. Every account has exactly one.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission is a single privileged action. Handlers check permissions,
+// never roles, so what each role may do is decided only by the matrix below.
+type Permission string
+
+const (
+	PermViewMetrics           Permission = "metrics:view"
+	PermResetDatabase         Permission = "database:reset"
+	PermManageModerationRules Permission = "moderation:rules"
+	PermReviewReports         Permission = "moderation:review"
+	PermViewHiddenChirps      Permission = "chirps:view_hidden"
+	PermSuspendUsers          Permission = "users:suspend"
+	PermManageRoles           Permission = "users:roles"
+	PermManageLockouts        Permission = "users:lockouts"
+)
+
+var permissions = map[Role][]Permission{
+	RoleUser: {},
+	RoleModerator: {
+		PermReviewReports,
+		PermViewHiddenChirps,
+		PermSuspendUsers,
+	},
+	RoleAdmin: {
+		PermViewMetrics,
+		PermResetDatabase,
+		PermManageModerationRules,
+		PermReviewReports,
+		PermViewHiddenChirps,
+		PermSuspendUsers,
+		PermManageRoles,
+		PermManageLockouts,
+	},
+}
+
+// ParseRole validates a role name.
+func ParseRole(name string) (Role, error) {
+	role := Role(name)
+	if _, ok := permissions[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", name)
+	}
+	return role, nil
+}
+
+// Can reports whether the role grants the permission. Unknown roles,
+// including the empty role, grant nothing.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range permissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package auth
+
+import "testing"
+
+func TestRolePermissions(t *testing.T) {
+	cases := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleUser, PermReviewReports, false},
+		{RoleModerator, PermReviewReports, true},
+		{RoleModerator, PermViewHiddenChirps, true},
+		{RoleModerator, PermManageModerationRules, false},
+		{RoleModerator, PermResetDatabase, false},
+		{RoleModerator, PermManageLockouts, false},
+		{RoleAdmin, PermManageRoles, true},
+		{RoleAdmin, PermManageLockouts, true},
+		{RoleAdmin, PermViewMetrics, true},
+		{Role(""), PermViewMetrics, false},
+	}
+	for _, c := range cases {
+		if got := c.role.Can(c.perm); got != c.want {
+			t.Errorf("Role %q Can(%q): expected %v, got %v", c.role, c.perm, c.want, got)
+		}
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole("moderator")
+	if err != nil || role != RoleModerator {
+		t.Fatalf("Expected moderator, got %q (%v)", role, err)
+	}
+	_, err = ParseRole("superuser")
+	if err == nil {
+		t.Fatalf("Expected error for unknown role, got nil")
+	}
+}
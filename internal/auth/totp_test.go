@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHOTPVectors(t *testing.T) {
+	// RFC 4226, Appendix D.
+	key := []byte("12345678901234567890")
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp(key, uint64(counter), 6); got != code {
+			t.Errorf("Counter %d: expected %s, got %s", counter, code, got)
+		}
+	}
+}
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238, Appendix B, SHA-1.
+	key := []byte("12345678901234567890")
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1234567890, "89005924"},
+		{20000000000, "65353130"},
+	}
+	for _, c := range cases {
+		if got := hotp(key, uint64(c.unix/totpPeriod), 8); got != c.code {
+			t.Errorf("Time %d: expected %s, got %s", c.unix, c.code, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+	code := hotp([]byte("12345678901234567890"), uint64(now.Unix()/totpPeriod), totpDigits)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	if !ok || step != now.Unix()/totpPeriod {
+		t.Fatalf("Expected code to validate at step %d, got %d (%v)", now.Unix()/totpPeriod, step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(totpPeriod*time.Second)); !ok {
+		t.Fatalf("Expected code from the previous step to validate")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(3*totpPeriod*time.Second)); ok {
+		t.Fatalf("Expected stale code to be rejected")
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	if _, ok := ValidateTOTP(secret, wrong, now); ok {
+		t.Fatalf("Expected wrong code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("JBSWY3DPEHPK3PXP", "Chirpy", "walt@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Chirpy:walt@example.com?") || !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+		t.Fatalf("Unexpected URI %q", uri)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("Error generating codes: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Fatalf("Unexpected code %q", code)
+		}
+		seen[code] = true
+	}
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Fatalf("Expected hashing to ignore case and dashes")
+	}
+}
+
+func TestMFAChallengeIsNotAnAccessToken(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+	challenge, err := MakeMFAChallenge(userID, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("Error making challenge: %v", err)
+	}
+	claims, err := ParseMFAChallenge(challenge, keys)
+	if err != nil || claims.Subject != userID.String() {
+		t.Fatalf("Expected challenge for %v, got %v (%v)", userID, claims, err)
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		t.Fatalf("Expected challenge to carry an ID and expiry, got %+v", claims)
+	}
+	if _, err := ParseJWT(challenge, keys); err == nil {
+		t.Fatalf("Expected challenge to be rejected as an access token")
+	}
+	access, _ := MakeJWT(userID, RoleUser, keys, time.Minute)
+	if _, err := ParseMFAChallenge(access, keys); err == nil {
+		t.Fatalf("Expected access token to be rejected as a challenge")
+	}
+}
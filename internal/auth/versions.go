@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTokenRevoked is returned for tokens issued before the user's current
+// token version, or for a session that has been logged out.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// ErrTokenCheckFailed is returned when a token's version or session can't be
+// looked up. The token may well be valid, so it isn't reported as one that
+// isn't.
+var ErrTokenCheckFailed = errors.New("couldn't check token")
+
+// A TokenVersionFunc looks up a user's current token version.
+type TokenVersionFunc func(ctx context.Context, userID uuid.UUID) (int32, error)
+
+// VersionCache caches users' token versions so that checking an access token
+// doesn't cost a database round trip. Bumping a user's version revokes every
+// access token issued before it; call Invalidate after bumping so this
+// process sees the change at once. Other processes see it within the TTL.
+type VersionCache struct {
+	ttlCache[uuid.UUID, int32]
+}
+
+func NewVersionCache(lookup TokenVersionFunc, ttl time.Duration) *VersionCache {
+	return &VersionCache{newTTLCache[uuid.UUID, int32](lookup, ttl)}
+}
+
+// Version returns userID's current token version.
+func (c *VersionCache) Version(ctx context.Context, userID uuid.UUID) (int32, error) {
+	return c.get(ctx, userID)
+}
+
+// Invalidate drops userID's cached version.
+func (c *VersionCache) Invalidate(userID uuid.UUID) {
+	c.invalidate(userID)
+}
+
+// A SessionRevokedFunc reports whether a session has been logged out.
+type SessionRevokedFunc func(ctx context.Context, sessionID uuid.UUID) (bool, error)
+
+// SessionCache caches whether sessions have been logged out, so that access
+// tokens issued to a revoked session stop working before they expire. Call
+// Invalidate after revoking a session so this process sees it at once. Other
+// processes see it within the TTL.
+type SessionCache struct {
+	ttlCache[uuid.UUID, bool]
+}
+
+func NewSessionCache(lookup SessionRevokedFunc, ttl time.Duration) *SessionCache {
+	return &SessionCache{newTTLCache[uuid.UUID, bool](lookup, ttl)}
+}
+
+// Revoked reports whether sessionID has been logged out.
+func (c *SessionCache) Revoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return c.get(ctx, sessionID)
+}
+
+// Invalidate drops sessionID's cached state.
+func (c *SessionCache) Invalidate(sessionID uuid.UUID) {
+	c.invalidate(sessionID)
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVersionCache(t *testing.T) {
+	lookups := 0
+	current := int32(3)
+	c := NewVersionCache(func(ctx context.Context, userID uuid.UUID) (int32, error) {
+		lookups++
+		return current, nil
+	}, time.Minute)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	for range 3 {
+		v, err := c.Version(context.Background(), userID)
+		if err != nil || v != 3 {
+			t.Fatalf("Expected version 3, got %d (%v)", v, err)
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("Expected 1 lookup while cached, got %d", lookups)
+	}
+
+	current = 4
+	c.Invalidate(userID)
+	v, _ := c.Version(context.Background(), userID)
+	if v != 4 || lookups != 2 {
+		t.Fatalf("Expected a fresh lookup after Invalidate, got version %d after %d lookups", v, lookups)
+	}
+
+	current = 5
+	now = now.Add(2 * time.Minute)
+	v, _ = c.Version(context.Background(), userID)
+	if v != 5 || lookups != 3 {
+		t.Fatalf("Expected a fresh lookup after the TTL, got version %d after %d lookups", v, lookups)
+	}
+}
@@ -0,0 +1,79 @@
+// Package chirptext extracts entities such as hashtags and @mentions from
+// chirp bodies.
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 64
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,15}$`)
+
+// ValidUsername reports whether username can be used as an @handle: 3 to 15
+// ASCII letters, digits or underscores.
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// Mentions returns the lowercased @handles in body, de-duplicated and in the
+// order they first appear. Words that could not be a username are skipped,
+// as are addresses like "me@example.com".
+func Mentions(body string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+	for _, handle := range prefixedWords(body, '@') {
+		handle = strings.ToLower(handle)
+		if !ValidUsername(handle) || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// Hashtags returns the normalized #tags in body, without the leading '#',
+// de-duplicated and in the order they first appear. A tag must start at a
+// word boundary and contain at least one letter, so "#1" and "a#b" are not
+// tags.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range prefixedWords(body, '#') {
+		tag = strings.ToLower(tag)
+		if len(tag) > maxHashtagLength || !strings.ContainsFunc(tag, unicode.IsLetter) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// prefixedWords returns every run of word characters that directly follows
+// prefix, where prefix itself is not preceded by a word character.
+func prefixedWords(body string, prefix rune) []string {
+	words := []string{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != prefix || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		if j > i+1 {
+			words = append(words, string(runes[i+1:j]))
+		}
+		i = j - 1
+	}
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,67 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tags := Hashtags("Loving #Go and #golang, #go again!")
+	expected := []string{"go", "golang"}
+	if !slices.Equal(tags, expected) {
+		t.Fatalf("Expected tags %v, got %v", expected, tags)
+	}
+}
+
+func TestHashtagsWordBoundary(t *testing.T) {
+	tags := Hashtags("email a#b or issue #1 or ##double")
+	expected := []string{"double"}
+	if !slices.Equal(tags, expected) {
+		t.Fatalf("Expected tags %v, got %v", expected, tags)
+	}
+}
+
+func TestHashtagsUnicode(t *testing.T) {
+	tags := Hashtags("#Café and #日本")
+	expected := []string{"café", "日本"}
+	if !slices.Equal(tags, expected) {
+		t.Fatalf("Expected tags %v, got %v", expected, tags)
+	}
+}
+
+func TestHashtagsNone(t *testing.T) {
+	tags := Hashtags("no tags here #")
+	if len(tags) != 0 {
+		t.Fatalf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	handles := Mentions("hey @Alice and @bob_2, cc @alice and me@example.com")
+	expected := []string{"alice", "bob_2"}
+	if !slices.Equal(handles, expected) {
+		t.Fatalf("Expected handles %v, got %v", expected, handles)
+	}
+}
+
+func TestMentionsInvalidHandles(t *testing.T) {
+	handles := Mentions("@ab is too short and @this_handle_is_too_long is too long")
+	if len(handles) != 0 {
+		t.Fatalf("Expected no handles, got %v", handles)
+	}
+}
+
+func TestValidUsername(t *testing.T) {
+	valid := []string{"abc", "Chirpy_Fan_123"}
+	for _, username := range valid {
+		if !ValidUsername(username) {
+			t.Fatalf("Expected %q to be valid", username)
+		}
+	}
+	invalid := []string{"", "ab", "has space", "émile", "sixteen_chars_xx"}
+	for _, username := range invalid {
+		if ValidUsername(username) {
+			t.Fatalf("Expected %q to be invalid", username)
+		}
+	}
+}
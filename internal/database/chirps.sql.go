@@ -7,23 +7,105 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countQuotes = `-- name: CountQuotes :many
+SELECT quote_of::uuid AS chirp_id, count(*) AS quote_count FROM chirps
+WHERE quote_of = ANY($1::uuid[]) AND deleted_at IS NULL AND moderation_status = 'published'
+GROUP BY quote_of
+`
+
+type CountQuotesRow struct {
+	ChirpID    uuid.UUID
+	QuoteCount int64
+}
+
+func (q *Queries) CountQuotes(ctx context.Context, chirpIds []uuid.UUID) ([]CountQuotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countQuotes, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountQuotesRow
+	for rows.Next() {
+		var i CountQuotesRow
+		if err := rows.Scan(&i.ChirpID, &i.QuoteCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countReplies = `-- name: CountReplies :many
+SELECT in_reply_to::uuid AS chirp_id, count(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY($1::uuid[]) AND deleted_at IS NULL
+GROUP BY in_reply_to
+`
+
+type CountRepliesRow struct {
+	ChirpID    uuid.UUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountReplies(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepliesRow, error) {
+	rows, err := q.db.QueryContext(ctx, countReplies, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesRow
+	for rows.Next() {
+		var i CountRepliesRow
+		if err := rows.Scan(&i.ChirpID, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, moderation_status, moderation_rules)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body             string
+	UserID           uuid.UUID
+	InReplyTo        uuid.NullUUID
+	QuoteOf          uuid.NullUUID
+	ModerationStatus string
+	ModerationRules  []string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+		arg.ModerationStatus,
+		pq.Array(arg.ModerationRules),
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +113,12 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.ModerationStatus,
+		pq.Array(&i.ModerationRules),
 	)
 	return i, err
 }
@@ -45,8 +133,94 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getActiveChirpByID = `-- name: GetActiveChirpByID :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+`
+
+func (q *Queries) GetActiveChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getActiveChirpByID, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.ModerationStatus,
+		pq.Array(&i.ModerationRules),
+	)
+	return i, err
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, depth) AS (
+    SELECT in_reply_to, 1 FROM chirps
+    WHERE chirps.id = $1 AND in_reply_to IS NOT NULL
+    UNION ALL
+    SELECT c.in_reply_to, a.depth + 1 FROM chirps c
+    JOIN ancestors a ON c.id = a.id
+    WHERE c.in_reply_to IS NOT NULL AND a.depth < $2::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.deleted_at, chirps.edited_at, chirps.quote_of, chirps.moderation_status, chirps.moderation_rules FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+ORDER BY ancestors.depth DESC
+`
+
+type GetChirpAncestorsParams struct {
+	ID       uuid.UUID
+	MaxDepth int32
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.ModerationStatus,
+			pq.Array(&i.ModerationRules),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
 WHERE id = $1
 `
 
@@ -59,17 +233,411 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.ModerationStatus,
+		pq.Array(&i.ModerationRules),
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT chirps.id, 1 FROM chirps
+    WHERE in_reply_to = $1
+    UNION ALL
+    SELECT c.id, d.depth + 1 FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+    WHERE d.depth < $2::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.deleted_at, chirps.edited_at, chirps.quote_of, chirps.moderation_status, chirps.moderation_rules FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+ORDER BY chirps.created_at ASC, chirps.id ASC
+`
+
+type GetChirpDescendantsParams struct {
+	ID       uuid.UUID
+	MaxDepth int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.ID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.ModerationStatus,
+			pq.Array(&i.ModerationRules),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.ModerationStatus,
+		pq.Array(&i.ModerationRules),
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
+WHERE id = ANY($1::uuid[])
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.ModerationStatus,
+			pq.Array(&i.ModerationRules),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasReplies = `-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps WHERE in_reply_to = $1::uuid
+)
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) HasReplies(ctx context.Context, chirpID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasReplies, chirpID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isChirpEditable = `-- name: IsChirpEditable :one
+SELECT created_at > NOW() - make_interval(secs => $1::float8) AS editable
+FROM chirps
+WHERE id = $2
+`
+
+type IsChirpEditableParams struct {
+	EditWindowSeconds float64
+	ID                uuid.UUID
+}
+
+func (q *Queries) IsChirpEditable(ctx context.Context, arg IsChirpEditableParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpEditable, arg.EditWindowSeconds, arg.ID)
+	var editable bool
+	err := row.Scan(&editable)
+	return editable, err
+}
+
+const listAuthorFeedAsc = `-- name: ListAuthorFeedAsc :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.user_id = $1 AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = $2::uuid OR $3::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE rechirps.user_id = $1 AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = $2::uuid OR $3::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE $4::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) > ($4, $5::uuid)
+ORDER BY feed.activity_at ASC, feed.chirp_id ASC
+LIMIT $6
+`
+
+type ListAuthorFeedAscParams struct {
+	AuthorID        uuid.UUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type ListAuthorFeedAscRow struct {
+	ChirpID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+	ActivityAt  time.Time
+}
+
+func (q *Queries) ListAuthorFeedAsc(ctx context.Context, arg ListAuthorFeedAscParams) ([]ListAuthorFeedAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorFeedAsc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuthorFeedAscRow
+	for rows.Next() {
+		var i ListAuthorFeedAscRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpedBy, &i.ActivityAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuthorFeedDesc = `-- name: ListAuthorFeedDesc :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.user_id = $1 AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = $2::uuid OR $3::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE rechirps.user_id = $1 AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = $2::uuid OR $3::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = $2::uuid)
+        OR (blocks.blocker_id = $2::uuid AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $2::uuid AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE $4::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) < ($4, $5::uuid)
+ORDER BY feed.activity_at DESC, feed.chirp_id DESC
+LIMIT $6
+`
+
+type ListAuthorFeedDescParams struct {
+	AuthorID        uuid.UUID
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type ListAuthorFeedDescRow struct {
+	ChirpID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+	ActivityAt  time.Time
+}
+
+func (q *Queries) ListAuthorFeedDesc(ctx context.Context, arg ListAuthorFeedDescParams) ([]ListAuthorFeedDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorFeedDesc,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuthorFeedDescRow
+	for rows.Next() {
+		var i ListAuthorFeedDescRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpedBy, &i.ActivityAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
+WHERE deleted_at IS NULL AND (moderation_status = 'published' OR user_id = $1::uuid OR $2::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $1::uuid)
+    OR (blocks.blocker_id = $1::uuid AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $1::uuid AND mutes.muted_id = chirps.user_id
+)
+AND ($3::timestamp IS NULL OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +651,12 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.ModerationStatus,
+			pq.Array(&i.ModerationRules),
 		); err != nil {
 			return nil, err
 		}
@@ -97,14 +671,43 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsByUserID = `-- name: GetChirpsByUserID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules FROM chirps
+WHERE deleted_at IS NULL AND (moderation_status = 'published' OR user_id = $1::uuid OR $2::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $1::uuid)
+    OR (blocks.blocker_id = $1::uuid AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = $1::uuid AND mutes.muted_id = chirps.user_id
+)
+AND ($3::timestamp IS NULL OR (created_at, id) < ($3, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUserID, userID)
+type ListChirpsDescParams struct {
+	ViewerID        uuid.NullUUID
+	IncludeHidden   bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.ViewerID,
+		arg.IncludeHidden,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +721,12 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.EditedAt,
+			&i.QuoteOf,
+			&i.ModerationStatus,
+			pq.Array(&i.ModerationRules),
 		); err != nil {
 			return nil, err
 		}
@@ -131,3 +740,245 @@ func (q *Queries) GetChirpsByUserID(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const listTimeline = `-- name: ListTimeline :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+    AND (chirps.user_id = $1 OR chirps.user_id IN (
+        SELECT followee_id FROM follows WHERE follower_id = $1
+    ))
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $1)
+        OR (blocks.blocker_id = $1 AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $1 AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+    AND (rechirps.user_id = $1 OR rechirps.user_id IN (
+        SELECT followee_id FROM follows WHERE follower_id = $1
+    ))
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $1)
+        OR (blocks.blocker_id = $1 AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $1 AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = $1)
+        OR (blocks.blocker_id = $1 AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $1 AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE $2::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) < ($2, $3::uuid)
+ORDER BY feed.activity_at DESC, feed.chirp_id DESC
+LIMIT $4
+`
+
+type ListTimelineParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageLimit       int32
+}
+
+type ListTimelineRow struct {
+	ChirpID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+	ActivityAt  time.Time
+}
+
+func (q *Queries) ListTimeline(ctx context.Context, arg ListTimelineParams) ([]ListTimelineRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTimelineRow
+	for rows.Next() {
+		var i ListTimelineRow
+		if err := rows.Scan(&i.ChirpID, &i.RechirpedBy, &i.ActivityAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT ranked.id, ranked.rank,
+    ts_headline('english', replace(replace(replace(replace(replace(ranked.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'), websearch_to_tsquery('english', $1::text), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15')::text AS snippet
+FROM (
+    SELECT id, body, ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', $1::text))::float8 AS rank
+    FROM chirps
+    WHERE deleted_at IS NULL AND moderation_status = 'published'
+    AND to_tsvector('english', body) @@ websearch_to_tsquery('english', $1::text)
+    AND ($2::uuid IS NULL OR user_id = $2)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $3::uuid)
+        OR (blocks.blocker_id = $3::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = $3::uuid AND mutes.muted_id = chirps.user_id
+    )
+) ranked
+WHERE $4::float8 IS NULL OR (ranked.rank, ranked.id) < ($4, $5::uuid)
+ORDER BY ranked.rank DESC, ranked.id DESC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	AuthorID   uuid.NullUUID
+	ViewerID   uuid.NullUUID
+	CursorRank sql.NullFloat64
+	CursorID   uuid.NullUUID
+	PageLimit  int32
+}
+
+type SearchChirpsRow struct {
+	ID      uuid.UUID
+	Rank    float64
+	Snippet string
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(&i.ID, &i.Rank, &i.Snippet); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChirpModerationStatus = `-- name: SetChirpModerationStatus :exec
+UPDATE chirps
+SET moderation_status = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetChirpModerationStatusParams struct {
+	ID               uuid.UUID
+	ModerationStatus string
+}
+
+func (q *Queries) SetChirpModerationStatus(ctx context.Context, arg SetChirpModerationStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setChirpModerationStatus, arg.ID, arg.ModerationStatus)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', deleted_at = now(), updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+    moderation_status = CASE WHEN moderation_status = 'hidden' THEN 'hidden' ELSE $3 END,
+    moderation_rules = $4, updated_at = now(), edited_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, edited_at, quote_of, moderation_status, moderation_rules
+`
+
+type UpdateChirpBodyParams struct {
+	ID               uuid.UUID
+	Body             string
+	ModerationStatus string
+	ModerationRules  []string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody,
+		arg.ID,
+		arg.Body,
+		arg.ModerationStatus,
+		pq.Array(arg.ModerationRules),
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.EditedAt,
+		&i.QuoteOf,
+		&i.ModerationStatus,
+		pq.Array(&i.ModerationRules),
+	)
+	return i, err
+}
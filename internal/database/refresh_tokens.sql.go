@@ -12,49 +12,233 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-VALUES ($1, NOW(), NOW(), $2, NOW() + INTERVAL '60 days')
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, family_id, expires_at)
+VALUES ($1, NOW(), NOW(), $2, $3, NOW() + INTERVAL '60 days')
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token  string
-	UserID uuid.UUID
+	TokenHash string
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.TokenHash, arg.UserID, arg.FamilyID)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
 	)
 	return i, err
 }
 
-const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT user_id FROM refresh_tokens
-WHERE token = $1 AND expires_at > NOW() AND revoked_at IS NULL
+const createTokenFamily = `-- name: CreateTokenFamily :one
+INSERT INTO token_families (id, user_id, created_at, user_agent, ip_address, last_used_at)
+VALUES (gen_random_uuid(), $1, NOW(), $2, $3, NOW())
+RETURNING id, user_id, created_at, revoked_at, user_agent, ip_address, last_used_at
+`
+
+type CreateTokenFamilyParams struct {
+	UserID    uuid.UUID
+	UserAgent string
+	IpAddress string
+}
+
+func (q *Queries) CreateTokenFamily(ctx context.Context, arg CreateTokenFamilyParams) (TokenFamily, error) {
+	row := q.db.QueryRowContext(ctx, createTokenFamily, arg.UserID, arg.UserAgent, arg.IpAddress)
+	var i TokenFamily
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at FROM refresh_tokens
+WHERE token_hash = $1 AND expires_at > NOW()
+FOR UPDATE
+`
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const getTokenFamily = `-- name: GetTokenFamily :one
+SELECT id, user_id, created_at, revoked_at, user_agent, ip_address, last_used_at FROM token_families
+WHERE id = $1
+`
+
+func (q *Queries) GetTokenFamily(ctx context.Context, id uuid.UUID) (TokenFamily, error) {
+	row := q.db.QueryRowContext(ctx, getTokenFamily, id)
+	var i TokenFamily
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.RevokedAt,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const isTokenFamilyRevoked = `-- name: IsTokenFamilyRevoked :one
+SELECT revoked_at IS NOT NULL AS revoked FROM token_families
+WHERE id = $1
+`
+
+func (q *Queries) IsTokenFamilyRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isTokenFamilyRevoked, id)
+	var revoked bool
+	err := row.Scan(&revoked)
+	return revoked, err
+}
+
+const listActiveTokenFamilies = `-- name: ListActiveTokenFamilies :many
+SELECT id, user_id, created_at, revoked_at, user_agent, ip_address, last_used_at FROM token_families
+WHERE token_families.user_id = $1 AND token_families.revoked_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE refresh_tokens.family_id = token_families.id
+    AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.rotated_at IS NULL AND refresh_tokens.expires_at > NOW()
+)
+ORDER BY token_families.last_used_at DESC
 `
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
-	var user_id uuid.UUID
-	err := row.Scan(&user_id)
-	return user_id, err
+func (q *Queries) ListActiveTokenFamilies(ctx context.Context, userID uuid.UUID) ([]TokenFamily, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveTokenFamilies, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TokenFamily
+	for rows.Next() {
+		var i TokenFamily
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.RevokedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+const revokeOtherTokenFamilies = `-- name: RevokeOtherTokenFamilies :exec
+WITH families AS (
+    UPDATE token_families
+    SET revoked_at = NOW()
+    WHERE token_families.user_id = $1::uuid AND token_families.id <> $2::uuid AND token_families.revoked_at IS NULL
+)
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1
+WHERE refresh_tokens.user_id = $1::uuid AND refresh_tokens.family_id <> $2::uuid AND refresh_tokens.revoked_at IS NULL
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+type RevokeOtherTokenFamiliesParams struct {
+	UserID uuid.UUID
+	KeepID uuid.UUID
+}
+
+func (q *Queries) RevokeOtherTokenFamilies(ctx context.Context, arg RevokeOtherTokenFamiliesParams) error {
+	_, err := q.db.ExecContext(ctx, revokeOtherTokenFamilies, arg.UserID, arg.KeepID)
+	return err
+}
+
+const revokeTokenFamily = `-- name: RevokeTokenFamily :exec
+WITH family AS (
+    UPDATE token_families
+    SET revoked_at = COALESCE(token_families.revoked_at, NOW())
+    WHERE token_families.id = $1::uuid
+)
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE refresh_tokens.family_id = $1::uuid AND refresh_tokens.revoked_at IS NULL
+`
+
+func (q *Queries) RevokeTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeTokenFamily, familyID)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+WITH families AS (
+    UPDATE token_families
+    SET revoked_at = NOW()
+    WHERE token_families.user_id = $1::uuid AND token_families.revoked_at IS NULL
+)
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE refresh_tokens.user_id = $1::uuid AND refresh_tokens.revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :exec
+UPDATE refresh_tokens
+SET rotated_at = NOW(), updated_at = NOW()
+WHERE token_hash = $1
+`
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, rotateRefreshToken, tokenHash)
+	return err
+}
+
+const touchTokenFamily = `-- name: TouchTokenFamily :exec
+UPDATE token_families
+SET user_agent = $2, ip_address = $3, last_used_at = NOW()
+WHERE id = $1
+`
+
+type TouchTokenFamilyParams struct {
+	ID        uuid.UUID
+	UserAgent string
+	IpAddress string
+}
+
+func (q *Queries) TouchTokenFamily(ctx context.Context, arg TouchTokenFamilyParams) error {
+	_, err := q.db.ExecContext(ctx, touchTokenFamily, arg.ID, arg.UserAgent, arg.IpAddress)
 	return err
 }
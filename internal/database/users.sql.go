@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,7 +25,7 @@ func (q *Queries) ClearUsers(ctx context.Context) error {
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, password)
 VALUES (gen_random_uuid(), now(), now(), $1, $2)
-RETURNING id, created_at, updated_at, email, is_chirpy_red
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website
 `
 
 type CreateUserParams struct {
@@ -33,11 +34,21 @@ type CreateUserParams struct {
 }
 
 type CreateUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Email       string
-	IsChirpyRed bool
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
@@ -49,21 +60,73 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
 
+const deactivateUser = `-- name: DeactivateUser :one
+UPDATE users
+SET deactivated_at = now(), delete_after = now() + make_interval(secs => $1::float8), token_version = token_version + 1, updated_at = now()
+WHERE id = $2
+RETURNING delete_after
+`
+
+type DeactivateUserParams struct {
+	GraceSeconds float64
+	ID           uuid.UUID
+}
+
+func (q *Queries) DeactivateUser(ctx context.Context, arg DeactivateUserParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, deactivateUser, arg.GraceSeconds, arg.ID)
+	var delete_after sql.NullTime
+	err := row.Scan(&delete_after)
+	return delete_after, err
+}
+
+const deleteExpiredUsers = `-- name: DeleteExpiredUsers :execrows
+DELETE FROM users
+WHERE delete_after <= now()
+`
+
+func (q *Queries) DeleteExpiredUsers(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredUsers)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website FROM users
 WHERE email = $1
 `
 
 type GetUserByEmailRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Email       string
-	IsChirpyRed bool
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
 }
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
@@ -75,10 +138,78 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website FROM users
+WHERE id = $1
+`
+
+type GetUserByIDRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i GetUserByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
 
+const getUserIDByUsername = `-- name: GetUserIDByUsername :one
+SELECT id FROM users
+WHERE lower(username) = lower($1)
+`
+
+func (q *Queries) GetUserIDByUsername(ctx context.Context, lower string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getUserIDByUsername, lower)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getUserPassword = `-- name: GetUserPassword :one
 SELECT password FROM users
 WHERE email = $1
@@ -91,6 +222,291 @@ func (q *Queries) GetUserPassword(ctx context.Context, email string) (string, er
 	return password, err
 }
 
+const getUserProfile = `-- name: GetUserProfile :one
+SELECT users.id, users.created_at, users.username, users.display_name, users.bio, users.avatar_url, users.location, users.website, users.is_chirpy_red,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL AND chirps.moderation_status = 'published') AS chirp_count
+FROM users
+WHERE users.id = $1 AND users.deactivated_at IS NULL
+`
+
+type GetUserProfileRow struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Username       sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	Location       string
+	Website        string
+	IsChirpyRed    bool
+	FollowerCount  int64
+	FollowingCount int64
+	ChirpCount     int64
+}
+
+func (q *Queries) GetUserProfile(ctx context.Context, id uuid.UUID) (GetUserProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfile, id)
+	var i GetUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+		&i.IsChirpyRed,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
+	)
+	return i, err
+}
+
+const getUserTokenVersion = `-- name: GetUserTokenVersion :one
+SELECT token_version FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserTokenVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getUserTokenVersion, id)
+	var token_version int32
+	err := row.Scan(&token_version)
+	return token_version, err
+}
+
+const patchUser = `-- name: PatchUser :one
+UPDATE users
+SET password = COALESCE($1, password),
+    username = COALESCE($2, username),
+    display_name = COALESCE($3, display_name),
+    bio = COALESCE($4, bio),
+    avatar_url = COALESCE($5, avatar_url),
+    location = COALESCE($6, location),
+    website = COALESCE($7, website),
+    token_version = token_version + CASE WHEN $1::text IS NULL THEN 0 ELSE 1 END,
+    updated_at = now()
+WHERE id = $8
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website
+`
+
+type PatchUserParams struct {
+	Password    sql.NullString
+	Username    sql.NullString
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	AvatarUrl   sql.NullString
+	Location    sql.NullString
+	Website     sql.NullString
+	ID          uuid.UUID
+}
+
+type PatchUserRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
+}
+
+func (q *Queries) PatchUser(ctx context.Context, arg PatchUserParams) (PatchUserRow, error) {
+	row := q.db.QueryRowContext(ctx, patchUser,
+		arg.Password,
+		arg.Username,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.Location,
+		arg.Website,
+		arg.ID,
+	)
+	var i PatchUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const promoteFirstAdmin = `-- name: PromoteFirstAdmin :one
+UPDATE users
+SET role = 'admin', updated_at = now()
+WHERE email = $1 AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin')
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website
+`
+
+type PromoteFirstAdminRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
+}
+
+func (q *Queries) PromoteFirstAdmin(ctx context.Context, email string) (PromoteFirstAdminRow, error) {
+	row := q.db.QueryRowContext(ctx, promoteFirstAdmin, email)
+	var i PromoteFirstAdminRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const reactivateUser = `-- name: ReactivateUser :exec
+UPDATE users
+SET deactivated_at = NULL, delete_after = NULL, updated_at = now()
+WHERE id = $1 AND deactivated_at IS NOT NULL
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, reactivateUser, id)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :one
+UPDATE users
+SET password = $2, token_version = token_version + 1, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website
+`
+
+type SetUserPasswordParams struct {
+	ID       uuid.UUID
+	Password string
+}
+
+type SetUserPasswordRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (SetUserPasswordRow, error) {
+	row := q.db.QueryRowContext(ctx, setUserPassword, arg.ID, arg.Password)
+	var i SetUserPasswordRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, token_version = token_version + 1, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, now()), token_version = token_version + 1, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, id)
+	return err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :exec
+UPDATE users
+SET suspended_at = NULL, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unsuspendUser, id)
+	return err
+}
+
 const updateToChirpyRed = `-- name: UpdateToChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = true
@@ -102,36 +518,55 @@ func (q *Queries) UpdateToChirpyRed(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const verifyUserEmail = `-- name: VerifyUserEmail :one
 UPDATE users
-SET email = $2, password = $3, updated_at = now()
+SET email = $2, email_verified_at = now(), updated_at = now()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website
 `
 
-type UpdateUserParams struct {
-	ID       uuid.UUID
-	Email    string
-	Password string
+type VerifyUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
 }
 
-type UpdateUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Email       string
-	IsChirpyRed bool
+type VerifyUserEmailRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	Username        sql.NullString
+	SuspendedAt     sql.NullTime
+	Role            string
+	TokenVersion    int32
+	EmailVerifiedAt sql.NullTime
+	DisplayName     string
+	Bio             string
+	AvatarUrl       string
+	Location        string
+	Website         string
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.Password)
-	var i UpdateUserRow
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (VerifyUserEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i VerifyUserEmailRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.SuspendedAt,
+		&i.Role,
+		&i.TokenVersion,
+		&i.EmailVerifiedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+// maxAddressLength is the longest address SMTP can carry (RFC 5321).
+const maxAddressLength = 254
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// NormalizeAddress checks that addr is a bare RFC 5322 address
+// ("walt@example.com", not "Walt <walt@example.com>") with a domain, and
+// returns it trimmed and lowercased. Local parts are case-sensitive in
+// theory but not in practice, and folding them stops two accounts from
+// differing only by case.
+func NormalizeAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" || len(addr) > maxAddressLength {
+		return "", ErrInvalidAddress
+	}
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Name != "" || parsed.Address != addr {
+		return "", ErrInvalidAddress
+	}
+	at := strings.LastIndex(addr, "@")
+	if at <= 0 || at == len(addr)-1 || strings.HasPrefix(addr[at+1:], "[") {
+		return "", ErrInvalidAddress
+	}
+	return strings.ToLower(addr), nil
+}
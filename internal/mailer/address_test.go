@@ -0,0 +1,32 @@
+package mailer
+
+import "testing"
+
+func TestNormalizeAddress(t *testing.T) {
+	valid := map[string]string{
+		"walt@example.com":          "walt@example.com",
+		"  Walt@Example.COM ":       "walt@example.com",
+		"first.last+tag@sub.ex.org": "first.last+tag@sub.ex.org",
+		"o'brien@example.com":       "o'brien@example.com",
+	}
+	for in, want := range valid {
+		got, err := NormalizeAddress(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeAddress(%q): expected %q, got %q (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{
+		"",
+		"walt",
+		"walt@",
+		"@example.com",
+		"Walt <walt@example.com>",
+		"walt@example.com, jesse@example.com",
+		"walt@[127.0.0.1]",
+		"walt example@example.com",
+	} {
+		if _, err := NormalizeAddress(in); err == nil {
+			t.Errorf("NormalizeAddress(%q): expected an error", in)
+		}
+	}
+}
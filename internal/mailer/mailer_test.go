@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := format("chirpy@example.com", Message{
+		To:      "walt@example.com",
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	}, now)
+	if err != nil {
+		t.Fatalf("Error formatting message: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"From: chirpy@example.com\r\n",
+		"To: walt@example.com\r\n",
+		"Subject: Hello\r\n",
+		"Date: Thu, 02 Jan 2025 03:04:05 +0000\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected message to contain %q, got %q", want, got)
+		}
+	}
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	_, err := format("chirpy@example.com", Message{
+		To:      "walt@example.com\r\nBcc: everyone@example.com",
+		Subject: "Hello",
+	}, time.Now())
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("Expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "chirpy@example.com")
+	err := m.Send(context.Background(), Message{To: "walt@example.com", Subject: "Reset", Body: "token"})
+	if err != nil {
+		t.Fatalf("Error sending: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 message file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "Subject: Reset") {
+		t.Fatalf("Unexpected message %q", data)
+	}
+}
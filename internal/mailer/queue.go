@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// A Job is background mail work, such as storing a token and sending a
+// link to it.
+type Job func(ctx context.Context)
+
+// Queue runs jobs on a fixed number of workers, so a burst of requests
+// can't start an unbounded number of sends. Jobs that don't fit are
+// rejected rather than left to pile up.
+type Queue struct {
+	jobs    chan Job
+	timeout time.Duration
+}
+
+// NewQueue returns a queue holding up to size waiting jobs, each of which
+// is given timeout to finish once it starts.
+func NewQueue(size int, timeout time.Duration) *Queue {
+	return &Queue{
+		jobs:    make(chan Job, size),
+		timeout: timeout,
+	}
+}
+
+// Enqueue adds job to the queue without blocking. It returns ErrQueueFull
+// when the queue has no room.
+func (q *Queue) Enqueue(job Job) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run starts workers that run jobs until ctx is done.
+func (q *Queue) Run(ctx context.Context, workers int) {
+	for range workers {
+		go q.work(ctx)
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			jobCtx, cancel := context.WithTimeout(ctx, q.timeout)
+			job(jobCtx)
+			cancel()
+		}
+	}
+}
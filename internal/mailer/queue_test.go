@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueueRejectsWhenFull(t *testing.T) {
+	q := NewQueue(1, time.Second)
+	noop := func(ctx context.Context) {}
+	if err := q.Enqueue(noop); err != nil {
+		t.Fatalf("Error queueing first job: %v", err)
+	}
+	if err := q.Enqueue(noop); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestQueueRunsJobs(t *testing.T) {
+	q := NewQueue(10, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Run(ctx, 2)
+	done := make(chan struct{}, 3)
+	for range 3 {
+		err := q.Enqueue(func(ctx context.Context) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("Expected job context to have a deadline")
+			}
+			done <- struct{}{}
+		})
+		if err != nil {
+			t.Fatalf("Error queueing job: %v", err)
+		}
+	}
+	for range 3 {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for jobs")
+		}
+	}
+}
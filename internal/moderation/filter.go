@@ -0,0 +1,184 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a byte range [Start, End) of the text passed to a Filter.
+type Span struct {
+	Start int
+	End   int
+}
+
+// A Filter finds the parts of a chirp that a rule objects to.
+type Filter interface {
+	Match(text string) []Span
+}
+
+// WordFilter matches whole words from a list, ignoring case. Words are runs
+// of letters and digits, so punctuation next to a word ("kerfuffle!") does
+// not hide it.
+type WordFilter struct {
+	words map[string]bool
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{words: map[string]bool{}}
+	for _, word := range words {
+		f.words[strings.ToLower(word)] = true
+	}
+	return f
+}
+
+func (f *WordFilter) Match(text string) []Span {
+	spans := []Span{}
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			spans = f.appendIfListed(spans, text, start, i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = f.appendIfListed(spans, text, start, len(text))
+	}
+	return spans
+}
+
+func (f *WordFilter) appendIfListed(spans []Span, text string, start, end int) []Span {
+	if f.words[strings.ToLower(text[start:end])] {
+		spans = append(spans, Span{Start: start, End: end})
+	}
+	return spans
+}
+
+// RegexFilter matches every occurrence of a regular expression.
+type RegexFilter struct {
+	re *regexp.Regexp
+}
+
+func NewRegexFilter(pattern string) (*RegexFilter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexFilter{re: re}, nil
+}
+
+func (f *RegexFilter) Match(text string) []Span {
+	spans := []Span{}
+	for _, loc := range f.re.FindAllStringIndex(text, -1) {
+		spans = append(spans, Span{Start: loc[0], End: loc[1]})
+	}
+	return spans
+}
+
+// NormalizingFilter runs another filter over a normalized copy of the text
+// so that look-alike spellings are caught: case is folded, invisible and
+// combining characters are dropped, full-width and accented Latin letters
+// become plain ASCII, and common digit and symbol substitutions ("k3rfuffl3",
+// "$harbert") are undone. Matches are mapped back onto the original text.
+type NormalizingFilter struct {
+	inner Filter
+}
+
+func NewNormalizingFilter(inner Filter) *NormalizingFilter {
+	return &NormalizingFilter{inner: inner}
+}
+
+func (f *NormalizingFilter) Match(text string) []Span {
+	normalized, starts, ends := normalize(text)
+	spans := []Span{}
+	for _, span := range f.inner.Match(normalized) {
+		if span.End <= span.Start {
+			continue
+		}
+		spans = append(spans, Span{Start: starts[span.Start], End: ends[span.End-1]})
+	}
+	return spans
+}
+
+var substitutions = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+var foldedLetters = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'ç': "c", 'č': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o",
+	'š': "s", 'ß': "ss",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u",
+	'ý': "y", 'ÿ': "y",
+	'ž': "z",
+}
+
+// normalize returns the normalized text along with, for every byte of it,
+// the start and end offsets of the original rune it came from.
+func normalize(text string) (string, []int, []int) {
+	var b strings.Builder
+	starts := []int{}
+	ends := []int{}
+	for i, r := range text {
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			size = 1
+		}
+		if unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+		out := string(r)
+		if folded, ok := foldedLetters[r]; ok {
+			out = folded
+		} else if sub, ok := substitutions[r]; ok && touchesLetter(text, i, size) {
+			out = string(sub)
+		}
+		b.WriteString(out)
+		for range len(out) {
+			starts = append(starts, i)
+			ends = append(ends, i+size)
+		}
+	}
+	return b.String(), starts, ends
+}
+
+// touchesLetter reports whether the rune at text[i:i+size] has a letter
+// directly before or after it. Substitutions only apply inside words, so a
+// standalone number like the 5 in "top 5" is left alone.
+func touchesLetter(text string, i, size int) bool {
+	if i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		if unicode.IsLetter(prev) {
+			return true
+		}
+	}
+	if i+size < len(text) {
+		next, _ := utf8.DecodeRuneInString(text[i+size:])
+		if unicode.IsLetter(next) {
+			return true
+		}
+	}
+	return false
+}
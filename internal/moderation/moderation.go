@@ -0,0 +1,271 @@
+// Package moderation checks chirp bodies against a configurable list of
+// rules. Each rule pairs a Filter with the action to take when it matches.
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Action is what happens to a chirp when a rule matches it.
+type Action string
+
+const (
+	ActionNone   Action = ""
+	ActionMask   Action = "mask"
+	ActionHold   Action = "hold"
+	ActionReject Action = "reject"
+)
+
+// severity orders actions so the strongest one that fired wins.
+var severity = map[Action]int{
+	ActionNone:   0,
+	ActionMask:   1,
+	ActionHold:   2,
+	ActionReject: 3,
+}
+
+const mask = "****"
+
+var ErrRuleNotFound = errors.New("moderation rule not found")
+
+// Rule is the configuration of a single filter. Type is "words" (match any
+// of Words) or "regex" (match Pattern). Normalize runs the filter over a
+// normalized copy of the text to catch look-alike spellings.
+type Rule struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Words     []string `json:"words,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Normalize bool     `json:"normalize,omitempty"`
+	Action    Action   `json:"action"`
+}
+
+type config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// DefaultRules are used when no config file exists yet.
+var DefaultRules = []Rule{
+	{
+		ID:        "profanity",
+		Type:      "words",
+		Words:     []string{"kerfuffle", "sharbert", "fornax"},
+		Normalize: true,
+		Action:    ActionMask,
+	},
+}
+
+// Result is the outcome of moderating a chirp. Text is the body with masked
+// spans replaced, Action the strongest action that fired, and Rules the IDs
+// of every rule that matched.
+type Result struct {
+	Text   string
+	Action Action
+	Rules  []string
+}
+
+type compiledRule struct {
+	Rule
+	filter Filter
+}
+
+// Moderator holds the active rules. Rules can be changed while the server
+// is running; changes are written back to the config file when there is one.
+type Moderator struct {
+	mu    sync.RWMutex
+	path  string
+	rules []compiledRule
+}
+
+// Load reads rules from the JSON config file at path. An empty path or a
+// missing file starts from DefaultRules.
+func Load(path string) (*Moderator, error) {
+	m := &Moderator{path: path}
+	err := m.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload re-reads the config file, replacing the active rules.
+func (m *Moderator) Reload() error {
+	rules := DefaultRules
+	if m.path != "" {
+		data, err := os.ReadFile(m.path)
+		if err == nil {
+			cfg := config{}
+			err = json.Unmarshal(data, &cfg)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", m.path, err)
+			}
+			rules = cfg.Rules
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	compiled := []compiledRule{}
+	for _, rule := range rules {
+		c, err := compile(rule)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, c)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = compiled
+	return nil
+}
+
+func compile(rule Rule) (compiledRule, error) {
+	if rule.ID == "" {
+		return compiledRule{}, errors.New("rule id is required")
+	}
+	if _, ok := severity[rule.Action]; !ok || rule.Action == ActionNone {
+		return compiledRule{}, fmt.Errorf("rule %s: unknown action %q", rule.ID, rule.Action)
+	}
+	var filter Filter
+	switch rule.Type {
+	case "words":
+		filter = NewWordFilter(rule.Words)
+	case "regex":
+		re, err := NewRegexFilter(rule.Pattern)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		filter = re
+	default:
+		return compiledRule{}, fmt.Errorf("rule %s: unknown type %q", rule.ID, rule.Type)
+	}
+	if rule.Normalize {
+		filter = NewNormalizingFilter(filter)
+	}
+	return compiledRule{Rule: rule, filter: filter}, nil
+}
+
+// Rules returns a copy of the active rules.
+func (m *Moderator) Rules() []Rule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rules := []Rule{}
+	for _, c := range m.rules {
+		rules = append(rules, c.Rule)
+	}
+	return rules
+}
+
+// PutRule adds rule, replacing any existing rule with the same ID.
+func (m *Moderator) PutRule(rule Rule) error {
+	c, err := compile(rule)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := slices.Clone(m.rules)
+	i := slices.IndexFunc(rules, func(existing compiledRule) bool { return existing.ID == rule.ID })
+	if i >= 0 {
+		rules[i] = c
+	} else {
+		rules = append(rules, c)
+	}
+	return m.commit(rules)
+}
+
+// DeleteRule removes the rule with the given ID.
+func (m *Moderator) DeleteRule(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := slices.DeleteFunc(slices.Clone(m.rules), func(existing compiledRule) bool { return existing.ID == id })
+	if len(rules) == len(m.rules) {
+		return ErrRuleNotFound
+	}
+	return m.commit(rules)
+}
+
+// commit persists rules and makes them active. m.mu must be held.
+func (m *Moderator) commit(rules []compiledRule) error {
+	if m.path != "" {
+		cfg := config{Rules: []Rule{}}
+		for _, c := range rules {
+			cfg.Rules = append(cfg.Rules, c.Rule)
+		}
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(m.path), ".moderation-*.json")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		err = os.Rename(tmp.Name(), m.path)
+		if err != nil {
+			return err
+		}
+	}
+	m.rules = rules
+	return nil
+}
+
+// Moderate runs every rule over text. Spans matched by mask rules are
+// replaced with "****"; all other text, including whitespace, is kept as is.
+func (m *Moderator) Moderate(text string) Result {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := Result{Action: ActionNone, Rules: []string{}}
+	masked := []Span{}
+	for _, rule := range m.rules {
+		spans := rule.filter.Match(text)
+		if len(spans) == 0 {
+			continue
+		}
+		result.Rules = append(result.Rules, rule.ID)
+		if severity[rule.Action] > severity[result.Action] {
+			result.Action = rule.Action
+		}
+		if rule.Action == ActionMask {
+			masked = append(masked, spans...)
+		}
+	}
+	result.Text = applyMask(text, masked)
+	return result
+}
+
+func applyMask(text string, spans []Span) string {
+	if len(spans) == 0 {
+		return text
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	var b strings.Builder
+	pos := 0
+	for _, span := range spans {
+		if span.End <= pos {
+			continue
+		}
+		// A span overlapping the previous one just extends its mask.
+		if span.Start >= pos {
+			b.WriteString(text[pos:span.Start])
+			b.WriteString(mask)
+		}
+		pos = span.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestModerateMasksPunctuatedWords(t *testing.T) {
+	m, err := Load("")
+	if err != nil {
+		t.Fatalf("Error loading default rules: %v", err)
+	}
+	result := m.Moderate("What a  Kerfuffle! Such a sharbert.")
+	expected := "What a  ****! Such a ****."
+	if result.Text != expected {
+		t.Fatalf("Expected text %q, got %q", expected, result.Text)
+	}
+	if result.Action != ActionMask {
+		t.Fatalf("Expected action %q, got %q", ActionMask, result.Action)
+	}
+	if !slices.Equal(result.Rules, []string{"profanity"}) {
+		t.Fatalf("Expected rules [profanity], got %v", result.Rules)
+	}
+}
+
+func TestModerateCleanText(t *testing.T) {
+	m, err := Load("")
+	if err != nil {
+		t.Fatalf("Error loading default rules: %v", err)
+	}
+	text := "I had something interesting for breakfast"
+	result := m.Moderate(text)
+	if result.Text != text || result.Action != ActionNone || len(result.Rules) != 0 {
+		t.Fatalf("Expected clean result, got %+v", result)
+	}
+}
+
+func TestNormalizingFilter(t *testing.T) {
+	f := NewNormalizingFilter(NewWordFilter([]string{"kerfuffle", "fornax"}))
+	text := "a k3rfuffl3 and a ｆｏｒｎａｘ and fo​rnax in top 5"
+	spans := f.Match(text)
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 matches, got %d", len(spans))
+	}
+	if text[spans[0].Start:spans[0].End] != "k3rfuffl3" {
+		t.Fatalf("Expected first match k3rfuffl3, got %q", text[spans[0].Start:spans[0].End])
+	}
+	if text[spans[1].Start:spans[1].End] != "ｆｏｒｎａｘ" {
+		t.Fatalf("Expected second match ｆｏｒｎａｘ, got %q", text[spans[1].Start:spans[1].End])
+	}
+}
+
+func TestModerateStrongestActionWins(t *testing.T) {
+	m, err := Load("")
+	if err != nil {
+		t.Fatalf("Error loading default rules: %v", err)
+	}
+	err = m.PutRule(Rule{ID: "links", Type: "regex", Pattern: `(?i)bit\.ly/\S+`, Action: ActionHold})
+	if err != nil {
+		t.Fatalf("Error adding rule: %v", err)
+	}
+	err = m.PutRule(Rule{ID: "banned", Type: "words", Words: []string{"spam"}, Action: ActionReject})
+	if err != nil {
+		t.Fatalf("Error adding rule: %v", err)
+	}
+	result := m.Moderate("kerfuffle at bit.ly/abc")
+	if result.Action != ActionHold {
+		t.Fatalf("Expected action %q, got %q", ActionHold, result.Action)
+	}
+	result = m.Moderate("kerfuffle spam at bit.ly/abc")
+	if result.Action != ActionReject {
+		t.Fatalf("Expected action %q, got %q", ActionReject, result.Action)
+	}
+	if !slices.Equal(result.Rules, []string{"profanity", "links", "banned"}) {
+		t.Fatalf("Expected all three rules to fire, got %v", result.Rules)
+	}
+}
+
+func TestRulesPersistToConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moderation.json")
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Error loading rules: %v", err)
+	}
+	err = m.PutRule(Rule{ID: "banned", Type: "words", Words: []string{"spam"}, Action: ActionReject})
+	if err != nil {
+		t.Fatalf("Error adding rule: %v", err)
+	}
+	err = m.DeleteRule("profanity")
+	if err != nil {
+		t.Fatalf("Error deleting rule: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected config file to be written: %v", err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Error reloading rules: %v", err)
+	}
+	rules := reloaded.Rules()
+	if len(rules) != 1 || rules[0].ID != "banned" {
+		t.Fatalf("Expected only the banned rule, got %+v", rules)
+	}
+}
+
+func TestInvalidRule(t *testing.T) {
+	m, err := Load("")
+	if err != nil {
+		t.Fatalf("Error loading default rules: %v", err)
+	}
+	err = m.PutRule(Rule{ID: "broken", Type: "regex", Pattern: "(", Action: ActionMask})
+	if err == nil {
+		t.Fatalf("Expected error for invalid pattern, got nil")
+	}
+	err = m.DeleteRule("missing")
+	if err != ErrRuleNotFound {
+		t.Fatalf("Expected ErrRuleNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+// Package throttle slows down password guessing. Failed logins are counted
+// per key (an account or a client IP) over a sliding window; a key that
+// fails too often is locked out, and each lockout lasts twice as long as the
+// one before it. State lives in a Store shared by every server instance,
+// with lockouts cached briefly in memory so that a locked-out client
+// hammering the server doesn't reach the database.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope is what a key counts failures for.
+type Scope string
+
+const (
+	ScopeAccount Scope = "account"
+	ScopeIP      Scope = "ip"
+	// ScopeResetIP counts password reset requests, rather than failed
+	// logins, per client IP.
+	ScopeResetIP Scope = "reset_ip"
+)
+
+// Key identifies a throttled account or IP.
+type Key struct {
+	Scope   Scope
+	Subject string
+}
+
+// AccountKey returns the key for the account with the given email. It
+// doesn't matter whether the account exists.
+func AccountKey(email string) Key {
+	return Key{Scope: ScopeAccount, Subject: strings.ToLower(strings.TrimSpace(email))}
+}
+
+func IPKey(ip string) Key {
+	return Key{Scope: ScopeIP, Subject: ip}
+}
+
+func ResetIPKey(ip string) Key {
+	return Key{Scope: ScopeResetIP, Subject: ip}
+}
+
+// Lockout is a key's lockout history. Level counts lockouts in a row; it
+// decides how long the next one lasts and is forgotten after a successful
+// login or a day without lockouts.
+type Lockout struct {
+	Key
+	Level       int
+	LockedUntil time.Time
+}
+
+var ErrNoLockout = errors.New("no lockout")
+
+// Store persists failures and lockouts.
+type Store interface {
+	RecordFailure(ctx context.Context, key Key, at time.Time) error
+	CountFailures(ctx context.Context, key Key, since time.Time) (int, error)
+	ClearFailures(ctx context.Context, key Key) error
+	// GetLockout returns ErrNoLockout when key has no lockout history.
+	GetLockout(ctx context.Context, key Key) (Lockout, error)
+	PutLockout(ctx context.Context, lockout Lockout) error
+	DeleteLockout(ctx context.Context, key Key) error
+	// ListLockouts returns the lockouts still in force at now.
+	ListLockouts(ctx context.Context, now time.Time) ([]Lockout, error)
+	Prune(ctx context.Context, failuresBefore, lockoutsBefore time.Time) error
+}
+
+// Policy is the limit for one scope: MaxFailures within Window locks the
+// key out, first for BaseLockout and then for twice as long each time, up
+// to MaxLockout.
+type Policy struct {
+	Window      time.Duration
+	MaxFailures int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+func (p Policy) lockoutFor(level int) time.Duration {
+	d := p.BaseLockout
+	for i := 1; i < level && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+// DefaultPolicies allow more failures per IP than per account, since many
+// users can share an address. Every password reset request counts as a
+// failure for its IP.
+var DefaultPolicies = map[Scope]Policy{
+	ScopeAccount: {Window: 15 * time.Minute, MaxFailures: 5, BaseLockout: time.Minute, MaxLockout: time.Hour},
+	ScopeIP:      {Window: 15 * time.Minute, MaxFailures: 20, BaseLockout: time.Minute, MaxLockout: time.Hour},
+	ScopeResetIP: {Window: 15 * time.Minute, MaxFailures: 10, BaseLockout: 15 * time.Minute, MaxLockout: 24 * time.Hour},
+}
+
+const (
+	// cacheTTL is how long a key's lockout state is trusted before the store
+	// is asked again, and so how long another instance's lockout or an
+	// admin's clear can take to be seen here.
+	cacheTTL = 5 * time.Second
+	// levelMemory is how long a lockout's level outlives the lockout.
+	levelMemory = 24 * time.Hour
+	// maxCachedKeys bounds the cache; past it, stale entries are swept.
+	maxCachedKeys = 10000
+)
+
+type cachedLockout struct {
+	lockedUntil time.Time
+	checked     time.Time
+}
+
+// Limiter applies policies to login attempts.
+type Limiter struct {
+	store    Store
+	policies map[Scope]Policy
+	mu       sync.Mutex
+	cache    map[Key]cachedLockout
+	now      func() time.Time
+}
+
+func NewLimiter(store Store, policies map[Scope]Policy) *Limiter {
+	return &Limiter{
+		store:    store,
+		policies: policies,
+		cache:    map[Key]cachedLockout{},
+		now:      time.Now,
+	}
+}
+
+// Check returns how long the caller must wait before any of keys may try
+// to log in again; zero means they may try now.
+func (l *Limiter) Check(ctx context.Context, keys ...Key) (time.Duration, error) {
+	now := l.now()
+	wait := time.Duration(0)
+	for _, key := range keys {
+		until, err := l.lockedUntil(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, until.Sub(now))
+	}
+	return wait, nil
+}
+
+func (l *Limiter) lockedUntil(ctx context.Context, key Key, now time.Time) (time.Time, error) {
+	l.mu.Lock()
+	cached, ok := l.cache[key]
+	l.mu.Unlock()
+	if ok && now.Sub(cached.checked) < cacheTTL {
+		return cached.lockedUntil, nil
+	}
+	lockout, err := l.store.GetLockout(ctx, key)
+	if err != nil && !errors.Is(err, ErrNoLockout) {
+		return time.Time{}, err
+	}
+	l.remember(key, lockout.LockedUntil, now)
+	return lockout.LockedUntil, nil
+}
+
+func (l *Limiter) remember(key Key, lockedUntil, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.cache) >= maxCachedKeys {
+		for k, c := range l.cache {
+			if now.Sub(c.checked) >= cacheTTL {
+				delete(l.cache, k)
+			}
+		}
+		if len(l.cache) >= maxCachedKeys {
+			clear(l.cache)
+		}
+	}
+	l.cache[key] = cachedLockout{lockedUntil: lockedUntil, checked: now}
+}
+
+// Fail records a failed login against each key and locks out the keys that
+// have now failed too often.
+func (l *Limiter) Fail(ctx context.Context, keys ...Key) error {
+	now := l.now()
+	for _, key := range keys {
+		policy, ok := l.policies[key.Scope]
+		if !ok {
+			continue
+		}
+		err := l.store.RecordFailure(ctx, key, now)
+		if err != nil {
+			return err
+		}
+		failures, err := l.store.CountFailures(ctx, key, now.Add(-policy.Window))
+		if err != nil {
+			return err
+		}
+		if failures < policy.MaxFailures {
+			continue
+		}
+		lockout, err := l.store.GetLockout(ctx, key)
+		if errors.Is(err, ErrNoLockout) {
+			lockout = Lockout{Key: key}
+		} else if err != nil {
+			return err
+		}
+		if lockout.LockedUntil.After(now) {
+			continue
+		}
+		lockout.Level++
+		lockout.LockedUntil = now.Add(policy.lockoutFor(lockout.Level))
+		err = l.store.PutLockout(ctx, lockout)
+		if err != nil {
+			return err
+		}
+		// The lockout replaces the failures that caused it; once it ends the
+		// key gets a fresh window, and the raised level makes the next
+		// lockout longer.
+		err = l.store.ClearFailures(ctx, key)
+		if err != nil {
+			return err
+		}
+		l.remember(key, lockout.LockedUntil, now)
+	}
+	return nil
+}
+
+// Succeed forgets key's failures and lockout history.
+func (l *Limiter) Succeed(ctx context.Context, key Key) error {
+	return l.Clear(ctx, key)
+}
+
+// Lockouts returns the lockouts currently in force.
+func (l *Limiter) Lockouts(ctx context.Context) ([]Lockout, error) {
+	return l.store.ListLockouts(ctx, l.now())
+}
+
+// Clear lifts key's lockout, if any, and forgets its failures and lockout
+// history.
+func (l *Limiter) Clear(ctx context.Context, key Key) error {
+	err := l.store.DeleteLockout(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = l.store.ClearFailures(ctx, key)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+	return nil
+}
+
+// Prune deletes failures that have left every window and lockouts whose
+// level has been forgotten.
+func (l *Limiter) Prune(ctx context.Context) error {
+	now := l.now()
+	window := time.Duration(0)
+	for _, policy := range l.policies {
+		window = max(window, policy.Window)
+	}
+	return l.store.Prune(ctx, now.Add(-window), now.Add(-levelMemory))
+}
+
+// Watch prunes the store every interval until ctx is done.
+func (l *Limiter) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := l.Prune(ctx)
+			if err != nil {
+				log.Printf("Error pruning login throttle state: %v", err)
+			}
+		}
+	}
+}
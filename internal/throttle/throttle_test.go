@@ -0,0 +1,192 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	failures map[Key][]time.Time
+	lockouts map[Key]Lockout
+	gets     int
+}
+
+func newMemStore() *memStore {
+	return &memStore{failures: map[Key][]time.Time{}, lockouts: map[Key]Lockout{}}
+}
+
+func (s *memStore) RecordFailure(ctx context.Context, key Key, at time.Time) error {
+	s.failures[key] = append(s.failures[key], at)
+	return nil
+}
+
+func (s *memStore) CountFailures(ctx context.Context, key Key, since time.Time) (int, error) {
+	n := 0
+	for _, at := range s.failures[key] {
+		if at.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memStore) ClearFailures(ctx context.Context, key Key) error {
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *memStore) GetLockout(ctx context.Context, key Key) (Lockout, error) {
+	s.gets++
+	lockout, ok := s.lockouts[key]
+	if !ok {
+		return Lockout{}, ErrNoLockout
+	}
+	return lockout, nil
+}
+
+func (s *memStore) PutLockout(ctx context.Context, lockout Lockout) error {
+	s.lockouts[lockout.Key] = lockout
+	return nil
+}
+
+func (s *memStore) DeleteLockout(ctx context.Context, key Key) error {
+	delete(s.lockouts, key)
+	return nil
+}
+
+func (s *memStore) ListLockouts(ctx context.Context, now time.Time) ([]Lockout, error) {
+	lockouts := []Lockout{}
+	for _, lockout := range s.lockouts {
+		if lockout.LockedUntil.After(now) {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	return lockouts, nil
+}
+
+func (s *memStore) Prune(ctx context.Context, failuresBefore, lockoutsBefore time.Time) error {
+	return nil
+}
+
+var testPolicies = map[Scope]Policy{
+	ScopeAccount: {Window: 10 * time.Minute, MaxFailures: 3, BaseLockout: time.Minute, MaxLockout: 3 * time.Minute},
+	ScopeIP:      {Window: 10 * time.Minute, MaxFailures: 5, BaseLockout: time.Minute, MaxLockout: time.Hour},
+}
+
+func newTestLimiter() (*Limiter, *memStore, *time.Time) {
+	store := newMemStore()
+	l := NewLimiter(store, testPolicies)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, store, &now
+}
+
+func failTimes(t *testing.T, l *Limiter, n int, keys ...Key) {
+	t.Helper()
+	for range n {
+		err := l.Fail(context.Background(), keys...)
+		if err != nil {
+			t.Fatalf("Fail: %v", err)
+		}
+	}
+}
+
+func TestLockoutAfterMaxFailures(t *testing.T) {
+	l, _, _ := newTestLimiter()
+	ctx := context.Background()
+	account := AccountKey("Walt@Example.com ")
+
+	failTimes(t, l, 2, account)
+	wait, _ := l.Check(ctx, AccountKey("walt@example.com"))
+	if wait != 0 {
+		t.Fatalf("Expected no lockout below the limit, got %v", wait)
+	}
+	failTimes(t, l, 1, account)
+	wait, _ = l.Check(ctx, AccountKey("walt@example.com"))
+	if wait != time.Minute {
+		t.Fatalf("Expected a 1m lockout, got %v", wait)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	l, _, now := newTestLimiter()
+	ctx := context.Background()
+	account := AccountKey("walt@example.com")
+
+	failTimes(t, l, 2, account)
+	*now = now.Add(11 * time.Minute)
+	failTimes(t, l, 1, account)
+	wait, _ := l.Check(ctx, account)
+	if wait != 0 {
+		t.Fatalf("Expected failures outside the window to be ignored, got %v", wait)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	l, _, now := newTestLimiter()
+	ctx := context.Background()
+	account := AccountKey("walt@example.com")
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		failTimes(t, l, 3, account)
+		wait, _ := l.Check(ctx, account)
+		if wait != want {
+			t.Fatalf("Expected a %v lockout, got %v", want, wait)
+		}
+		*now = now.Add(wait)
+	}
+
+	err := l.Succeed(ctx, account)
+	if err != nil {
+		t.Fatalf("Succeed: %v", err)
+	}
+	failTimes(t, l, 3, account)
+	wait, _ := l.Check(ctx, account)
+	if wait != time.Minute {
+		t.Fatalf("Expected backoff to reset after a success, got %v", wait)
+	}
+}
+
+func TestCheckUsesLongestLockout(t *testing.T) {
+	l, _, _ := newTestLimiter()
+	ctx := context.Background()
+	ip := IPKey("203.0.113.7")
+
+	failTimes(t, l, 5, ip)
+	wait, _ := l.Check(ctx, AccountKey("someone@example.com"), ip)
+	if wait != time.Minute {
+		t.Fatalf("Expected the IP lockout to apply, got %v", wait)
+	}
+}
+
+func TestClearAndCache(t *testing.T) {
+	l, store, now := newTestLimiter()
+	ctx := context.Background()
+	account := AccountKey("walt@example.com")
+
+	l.Check(ctx, account)
+	l.Check(ctx, account)
+	if store.gets != 1 {
+		t.Fatalf("Expected the second check to be cached, got %d store reads", store.gets)
+	}
+	*now = now.Add(cacheTTL)
+	l.Check(ctx, account)
+	if store.gets != 2 {
+		t.Fatalf("Expected the cache to expire, got %d store reads", store.gets)
+	}
+
+	failTimes(t, l, 3, account)
+	lockouts, _ := l.Lockouts(ctx)
+	if len(lockouts) != 1 || lockouts[0].Key != account {
+		t.Fatalf("Expected one lockout, got %+v", lockouts)
+	}
+	err := l.Clear(ctx, account)
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	wait, _ := l.Check(ctx, account)
+	if wait != 0 {
+		t.Fatalf("Expected no lockout after Clear, got %v", wait)
+	}
+}
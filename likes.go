@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rawChirp, err := cfg.visibleChirp(r.Context(), chirpID, userID, false)
+	if err != nil || rawChirp.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	err = cfg.dbQueries.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't like chirp")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	err = cfg.dbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unlike chirp")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	viewerID := auth.UserIDFromContext(r.Context())
+	rows, err := cfg.dbQueries.ListLikesByUser(r.Context(), database.ListLikesByUserParams{
+		UserID:          userID,
+		ViewerID:        nullViewerID(viewerID),
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get likes")
+		return
+	}
+	rows = trimPage(w, r, page, rows, func(row database.ListLikesByUserRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.ChirpID
+	})
+	ids := []uuid.UUID{}
+	for _, row := range rows {
+		ids = append(ids, row.ChirpID)
+	}
+	rawChirps, err := cfg.chirpsByIDs(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get likes")
+		return
+	}
+	chirps, err := cfg.buildChirps(r.Context(), rawChirps, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	respondWithJSON(w, 200, chirps)
+}
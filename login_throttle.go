@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
+)
+
+type lockout struct {
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	Level       int       `json:"level"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func respondTooManyLogins(w http.ResponseWriter, wait time.Duration) {
+	respondTooManyRequests(w, wait, "Too many login attempts")
+}
+
+func respondTooManyRequests(w http.ResponseWriter, wait time.Duration, msg string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithError(w, 429, msg)
+}
+
+func (cfg *apiConfig) handlerGetLockouts(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := cfg.loginLimiter.Lockouts(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get lockouts")
+		return
+	}
+	response := []lockout{}
+	for _, l := range lockouts {
+		response = append(response, lockout{
+			Scope:       string(l.Scope),
+			Subject:     l.Subject,
+			Level:       l.Level,
+			LockedUntil: l.LockedUntil,
+		})
+	}
+	respondWithJSON(w, 200, response)
+}
+
+func (cfg *apiConfig) handlerClearLockout(w http.ResponseWriter, r *http.Request) {
+	key := throttle.Key{Scope: throttle.Scope(r.PathValue("scope")), Subject: r.PathValue("subject")}
+	switch key.Scope {
+	case throttle.ScopeAccount:
+		key = throttle.AccountKey(key.Subject)
+	case throttle.ScopeIP, throttle.ScopeResetIP:
+	default:
+		respondWithError(w, 404, "Unknown lockout scope")
+		return
+	}
+	err := cfg.loginLimiter.Clear(r.Context(), key)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't clear lockout")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// throttleStore keeps login throttling state in Postgres so that every
+// server instance sees the same failures and lockouts.
+type throttleStore struct {
+	db *database.Queries
+}
+
+func (s throttleStore) RecordFailure(ctx context.Context, key throttle.Key, at time.Time) error {
+	return s.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Scope:    string(key.Scope),
+		Subject:  key.Subject,
+		FailedAt: at,
+	})
+}
+
+func (s throttleStore) CountFailures(ctx context.Context, key throttle.Key, since time.Time) (int, error) {
+	count, err := s.db.CountLoginFailures(ctx, database.CountLoginFailuresParams{
+		Scope:    string(key.Scope),
+		Subject:  key.Subject,
+		FailedAt: since,
+	})
+	return int(count), err
+}
+
+func (s throttleStore) ClearFailures(ctx context.Context, key throttle.Key) error {
+	return s.db.ClearLoginFailures(ctx, database.ClearLoginFailuresParams{
+		Scope:   string(key.Scope),
+		Subject: key.Subject,
+	})
+}
+
+func (s throttleStore) GetLockout(ctx context.Context, key throttle.Key) (throttle.Lockout, error) {
+	row, err := s.db.GetLoginLockout(ctx, database.GetLoginLockoutParams{
+		Scope:   string(key.Scope),
+		Subject: key.Subject,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return throttle.Lockout{}, throttle.ErrNoLockout
+	}
+	if err != nil {
+		return throttle.Lockout{}, err
+	}
+	return lockoutFromDB(row), nil
+}
+
+func (s throttleStore) PutLockout(ctx context.Context, l throttle.Lockout) error {
+	_, err := s.db.PutLoginLockout(ctx, database.PutLoginLockoutParams{
+		Scope:       string(l.Scope),
+		Subject:     l.Subject,
+		Level:       int32(l.Level),
+		LockedUntil: l.LockedUntil,
+	})
+	return err
+}
+
+func (s throttleStore) DeleteLockout(ctx context.Context, key throttle.Key) error {
+	return s.db.DeleteLoginLockout(ctx, database.DeleteLoginLockoutParams{
+		Scope:   string(key.Scope),
+		Subject: key.Subject,
+	})
+}
+
+func (s throttleStore) ListLockouts(ctx context.Context, now time.Time) ([]throttle.Lockout, error) {
+	rows, err := s.db.ListLoginLockouts(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	lockouts := []throttle.Lockout{}
+	for _, row := range rows {
+		lockouts = append(lockouts, lockoutFromDB(row))
+	}
+	return lockouts, nil
+}
+
+func (s throttleStore) Prune(ctx context.Context, failuresBefore, lockoutsBefore time.Time) error {
+	err := s.db.PruneLoginFailures(ctx, failuresBefore)
+	if err != nil {
+		return err
+	}
+	return s.db.PruneLoginLockouts(ctx, lockoutsBefore)
+}
+
+func lockoutFromDB(row database.LoginLockout) throttle.Lockout {
+	return throttle.Lockout{
+		Key:         throttle.Key{Scope: throttle.Scope(row.Scope), Subject: row.Subject},
+		Level:       int(row.Level),
+		LockedUntil: row.LockedUntil,
+	}
+}
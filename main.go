@@ -1,25 +1,47 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
 	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/mailer"
+	"github.com/ecmoser/Chirpy_HTTP/internal/moderation"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	dbQueries      *database.Queries
-	platform       string
-	tokenSecret    string
-	polkaApiKey    string
+	fileserverHits       atomic.Int32
+	db                   *sql.DB
+	dbQueries            *database.Queries
+	platform             string
+	jwtKeys              *auth.KeySet
+	polkaApiKey          string
+	editWindow           time.Duration
+	redEditWindow        time.Duration
+	moderator            *moderation.Moderator
+	authn                *auth.Authenticator
+	tokenVersions        *auth.VersionCache
+	revokedSessions      *auth.SessionCache
+	loginLimiter         *throttle.Limiter
+	mailer               mailer.Mailer
+	mailQueue            *mailer.Queue
+	baseURL              string
+	trustProxyHeaders    bool
+	requireVerifiedEmail bool
+	deletionGracePeriod  time.Duration
 }
 
 func respondWithError(w http.ResponseWriter, code int, msg string) {
@@ -40,6 +62,30 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	w.Write(data)
 }
 
+// durationFromEnv parses an optional duration setting such as "15m",
+// falling back to the default when it is unset.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return d
+}
+
+// isUniqueViolation reports whether err is PostgreSQL rejecting a write
+// because it would break the named unique constraint or index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
 func main() {
 	godotenv.Load()
 
@@ -50,33 +96,151 @@ func main() {
 	}
 	dbQueries := database.New(db)
 
+	// "chirpy bootstrap-admin <email>" promotes the first admin and exits.
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		if len(os.Args) != 3 {
+			log.Fatal("usage: chirpy bootstrap-admin <email>")
+		}
+		err = bootstrapAdmin(context.Background(), dbQueries, os.Args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s is now an admin", os.Args[2])
+		return
+	}
+
+	moderator, err := moderation.Load(os.Getenv("MODERATION_CONFIG"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// With JWT_KEY_DIR set, tokens are signed with the asymmetric keys in it;
+	// TOKEN_SECRET then only keeps older HS256 tokens valid until it is unset.
+	var jwtKeys *auth.KeySet
+	if keyDir := os.Getenv("JWT_KEY_DIR"); keyDir != "" {
+		jwtKeys, err = auth.LoadKeySet(keyDir, os.Getenv("TOKEN_SECRET"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		go jwtKeys.Watch(context.Background(), durationFromEnv("JWT_KEY_RELOAD_INTERVAL", time.Minute))
+	} else {
+		jwtKeys = auth.NewHMACKeySet(os.Getenv("TOKEN_SECRET"))
+	}
+
+	tokenVersions := auth.NewVersionCache(dbQueries.GetUserTokenVersion, durationFromEnv("TOKEN_VERSION_CACHE_TTL", 30*time.Second))
+	revokedSessions := auth.NewSessionCache(sessionRevokedFunc(dbQueries), durationFromEnv("TOKEN_VERSION_CACHE_TTL", 30*time.Second))
+
+	loginLimiter := throttle.NewLimiter(throttleStore{db: dbQueries}, throttle.DefaultPolicies)
+	go loginLimiter.Watch(context.Background(), 10*time.Minute)
+
+	var mail mailer.Mailer
+	if os.Getenv("MAILER") == "smtp" {
+		mail = mailer.NewSMTPMailer(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	} else {
+		mail = mailer.NewFileMailer(os.Getenv("MAIL_DIR"), os.Getenv("MAIL_FROM"))
+	}
+	mailQueue := mailer.NewQueue(100, time.Minute)
+	mailQueue.Run(context.Background(), 4)
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
 	const filepathRoot = "./app/"
 	const port = "8080"
 	apiCfg := apiConfig{
-		dbQueries:   dbQueries,
-		platform:    os.Getenv("PLATFORM"),
-		tokenSecret: os.Getenv("TOKEN_SECRET"),
-		polkaApiKey: os.Getenv("POLKA_KEY"),
+		db:                   db,
+		dbQueries:            dbQueries,
+		platform:             os.Getenv("PLATFORM"),
+		jwtKeys:              jwtKeys,
+		polkaApiKey:          os.Getenv("POLKA_KEY"),
+		editWindow:           durationFromEnv("CHIRP_EDIT_WINDOW", 15*time.Minute),
+		redEditWindow:        durationFromEnv("CHIRP_EDIT_WINDOW_RED", time.Hour),
+		moderator:            moderator,
+		authn:                auth.NewAuthenticator(jwtKeys, tokenVersions, revokedSessions),
+		tokenVersions:        tokenVersions,
+		revokedSessions:      revokedSessions,
+		loginLimiter:         loginLimiter,
+		mailer:               mail,
+		mailQueue:            mailQueue,
+		baseURL:              strings.TrimSuffix(baseURL, "/"),
+		requireVerifiedEmail: os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true",
+		trustProxyHeaders:    os.Getenv("TRUST_PROXY_HEADERS") == "true",
+		deletionGracePeriod:  durationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
 	}
+	go apiCfg.watchAccountDeletions(context.Background(), durationFromEnv("ACCOUNT_DELETION_INTERVAL", time.Hour))
 
 	mux := http.NewServeMux()
 
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
 
 	mux.HandleFunc("GET /api/healthz", handlerHealthz)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.handlerGetChirpByID)
+	mux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
+	mux.Handle("GET /api/chirps", apiCfg.authn.Optional(apiCfg.handlerGetChirps))
+	mux.Handle("GET /api/chirps/search", apiCfg.authn.Optional(apiCfg.handlerSearchChirps))
+	mux.Handle("GET /api/chirps/{id}", apiCfg.authn.Optional(apiCfg.handlerGetChirpByID))
+	mux.Handle("GET /api/chirps/{id}/thread", apiCfg.authn.Optional(apiCfg.handlerGetThread))
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerUserLogin)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.handlerLoginMFA)
+	mux.HandleFunc("POST /api/password-reset/request", apiCfg.handlerRequestPasswordReset)
+	mux.HandleFunc("POST /api/password-reset/confirm", apiCfg.handlerConfirmPasswordReset)
+	mux.HandleFunc("GET /api/verify-email", apiCfg.handlerShowVerifyEmail)
+	mux.HandleFunc("POST /api/verify-email", apiCfg.handlerVerifyEmail)
+	mux.Handle("POST /api/verify-email/resend", apiCfg.authn.Required(apiCfg.handlerResendVerification))
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevokeRefreshToken)
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
+	mux.Handle("POST /api/chirps", apiCfg.authn.Required(apiCfg.handlerCreateChirp))
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaWebhook)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("DELETE /api/chirps/{id}", apiCfg.handlerDeleteChirp)
+	mux.Handle("PUT /api/users", apiCfg.authn.Required(apiCfg.handlerReplaceUser))
+	mux.Handle("GET /api/users/me", apiCfg.authn.Required(apiCfg.handlerGetMe))
+	mux.Handle("PATCH /api/users/me", apiCfg.authn.Required(apiCfg.handlerUpdateUser))
+	mux.Handle("DELETE /api/users/me", apiCfg.authn.Required(apiCfg.handlerDeleteMe))
+	mux.Handle("GET /api/users/me/export", apiCfg.authn.Required(apiCfg.handlerExportMe))
+	mux.Handle("GET /api/users/{id_or_username}", apiCfg.authn.Optional(apiCfg.handlerGetProfile))
+	mux.Handle("DELETE /api/chirps/{id}", apiCfg.authn.Required(apiCfg.handlerDeleteChirp))
+	mux.Handle("PUT /api/chirps/{id}", apiCfg.authn.Required(apiCfg.handlerUpdateChirp))
+	mux.Handle("GET /api/chirps/{id}/revisions", apiCfg.authn.Optional(apiCfg.handlerGetChirpRevisions))
+	mux.Handle("POST /api/users/{id}/follow", apiCfg.authn.Required(apiCfg.handlerFollowUser))
+	mux.Handle("DELETE /api/users/{id}/follow", apiCfg.authn.Required(apiCfg.handlerUnfollowUser))
+	mux.HandleFunc("GET /api/users/{id}/followers", apiCfg.handlerGetFollowers)
+	mux.Handle("POST /api/users/{id}/block", apiCfg.authn.Required(apiCfg.handlerBlockUser))
+	mux.Handle("DELETE /api/users/{id}/block", apiCfg.authn.Required(apiCfg.handlerUnblockUser))
+	mux.Handle("GET /api/users/me/blocks", apiCfg.authn.Required(apiCfg.handlerGetBlocks))
+	mux.Handle("POST /api/users/{id}/mute", apiCfg.authn.Required(apiCfg.handlerMuteUser))
+	mux.Handle("DELETE /api/users/{id}/mute", apiCfg.authn.Required(apiCfg.handlerUnmuteUser))
+	mux.Handle("GET /api/users/me/mutes", apiCfg.authn.Required(apiCfg.handlerGetMutes))
+	mux.HandleFunc("GET /api/users/{id}/following", apiCfg.handlerGetFollowing)
+	mux.Handle("GET /api/timeline", apiCfg.authn.Required(apiCfg.handlerGetTimeline))
+	mux.Handle("POST /api/chirps/{id}/like", apiCfg.authn.Required(apiCfg.handlerLikeChirp))
+	mux.Handle("DELETE /api/chirps/{id}/like", apiCfg.authn.Required(apiCfg.handlerUnlikeChirp))
+	mux.Handle("POST /api/chirps/{id}/rechirp", apiCfg.authn.Required(apiCfg.handlerRechirp))
+	mux.Handle("DELETE /api/chirps/{id}/rechirp", apiCfg.authn.Required(apiCfg.handlerUndoRechirp))
+	mux.Handle("GET /api/users/{id}/likes", apiCfg.authn.Optional(apiCfg.handlerGetUserLikes))
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handlerGetTrendingTags)
+	mux.Handle("GET /api/tags/{tag}/chirps", apiCfg.authn.Optional(apiCfg.handlerGetTagChirps))
+	mux.Handle("GET /api/mentions", apiCfg.authn.Required(apiCfg.handlerGetMentions))
+	mux.Handle("POST /api/chirps/{id}/report", apiCfg.authn.Required(apiCfg.handlerReportChirp))
+	mux.Handle("POST /api/users/me/2fa/enroll", apiCfg.authn.Required(apiCfg.handlerEnrollMFA))
+	mux.Handle("POST /api/users/me/2fa/verify", apiCfg.authn.Required(apiCfg.handlerVerifyMFA))
+	mux.Handle("POST /api/users/me/2fa/disable", apiCfg.authn.Required(apiCfg.handlerDisableMFA))
+	mux.Handle("GET /api/sessions", apiCfg.authn.Required(apiCfg.handlerGetSessions))
+	mux.Handle("DELETE /api/sessions", apiCfg.authn.Required(apiCfg.handlerRevokeOtherSessions))
+	mux.Handle("DELETE /api/sessions/{id}", apiCfg.authn.Required(apiCfg.handlerRevokeSession))
 
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.Handle("GET /admin/metrics", apiCfg.authn.RequirePermission(auth.PermViewMetrics, apiCfg.handlerMetrics))
+	mux.Handle("POST /admin/reset", apiCfg.authn.RequirePermission(auth.PermResetDatabase, apiCfg.handlerReset))
+	mux.Handle("GET /admin/moderation/rules", apiCfg.authn.RequirePermission(auth.PermManageModerationRules, apiCfg.handlerGetModerationRules))
+	mux.Handle("POST /admin/moderation/rules", apiCfg.authn.RequirePermission(auth.PermManageModerationRules, apiCfg.handlerPutModerationRule))
+	mux.Handle("DELETE /admin/moderation/rules/{id}", apiCfg.authn.RequirePermission(auth.PermManageModerationRules, apiCfg.handlerDeleteModerationRule))
+	mux.Handle("POST /admin/moderation/reload", apiCfg.authn.RequirePermission(auth.PermManageModerationRules, apiCfg.handlerReloadModerationRules))
+	mux.Handle("GET /admin/moderation/queue", apiCfg.authn.RequirePermission(auth.PermReviewReports, apiCfg.handlerGetModerationQueue))
+	mux.Handle("POST /admin/chirps/{id}/resolve", apiCfg.authn.RequirePermission(auth.PermReviewReports, apiCfg.handlerResolveChirp))
+	mux.Handle("POST /admin/users/{id}/suspension", apiCfg.authn.RequirePermission(auth.PermSuspendUsers, apiCfg.handlerSuspendUser))
+	mux.Handle("DELETE /admin/users/{id}/suspension", apiCfg.authn.RequirePermission(auth.PermSuspendUsers, apiCfg.handlerUnsuspendUser))
+	mux.Handle("GET /admin/lockouts", apiCfg.authn.RequirePermission(auth.PermManageLockouts, apiCfg.handlerGetLockouts))
+	mux.Handle("DELETE /admin/lockouts/{scope}/{subject}", apiCfg.authn.RequirePermission(auth.PermManageLockouts, apiCfg.handlerClearLockout))
+	mux.Handle("PUT /admin/users/{id}/role", apiCfg.authn.RequirePermission(auth.PermManageRoles, apiCfg.handlerSetUserRole))
 
 	srv := &http.Server{
 		Addr:    ":" + port,
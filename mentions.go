@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+type mention struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rawChirps, err := cfg.dbQueries.ListMentionedChirps(r.Context(), database.ListMentionedChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get mentions")
+		return
+	}
+	rawChirps = trimPage(w, r, page, rawChirps, chirpPosition)
+	chirps, err := cfg.buildChirps(r.Context(), rawChirps, userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	respondWithJSON(w, 200, chirps)
+}
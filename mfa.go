@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
+	"github.com/google/uuid"
+)
+
+const (
+	mfaIssuer            = "Chirpy"
+	mfaChallengeLifetime = 5 * time.Minute
+	recoveryCodeCount    = 10
+)
+
+// mfaChallenge is the login response for accounts with two-factor
+// authentication: MFAToken is traded, along with a code, for real tokens at
+// POST /api/login/mfa.
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// mfaEnabled reports whether the user has finished enrolling in TOTP.
+func (cfg *apiConfig) mfaEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	cred, err := cfg.dbQueries.GetTOTPCredential(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return cred.EnabledAt.Valid, nil
+}
+
+// checkTOTP reports whether code is valid for the user's TOTP secret and
+// hasn't been used before.
+func (cfg *apiConfig) checkTOTP(ctx context.Context, cred database.TotpCredential, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(cred.Secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	used, err := cfg.dbQueries.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		UserID:       cred.UserID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return used == 1, nil
+}
+
+func (cfg *apiConfig) handlerEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't start enrollment")
+		return
+	}
+	enabled, err := cfg.mfaEnabled(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't start enrollment")
+		return
+	}
+	if enabled {
+		respondWithError(w, 409, "Two-factor authentication is already enabled")
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't start enrollment")
+		return
+	}
+	err = cfg.dbQueries.PutPendingTOTPCredential(r.Context(), database.PutPendingTOTPCredentialParams{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't start enrollment")
+		return
+	}
+	respondWithJSON(w, 200, struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}{
+		Secret:     secret,
+		OtpauthURI: auth.TOTPURI(secret, mfaIssuer, dbUser.Email),
+	})
+}
+
+// handlerVerifyMFA finishes enrollment: the first code from the
+// authenticator app proves it was set up correctly, and only then is 2FA
+// turned on and the recovery codes handed out.
+func (cfg *apiConfig) handlerVerifyMFA(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Code string `json:"code"`
+	}
+	userID := auth.UserIDFromContext(r.Context())
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	cred, err := cfg.dbQueries.GetTOTPCredential(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "Two-factor enrollment has not been started")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify code")
+		return
+	}
+	if cred.EnabledAt.Valid {
+		respondWithError(w, 409, "Two-factor authentication is already enabled")
+		return
+	}
+	step, ok := auth.ValidateTOTP(cred.Secret, rBody.Code, time.Now())
+	if !ok {
+		respondWithError(w, 400, "Invalid code")
+		return
+	}
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't enable two-factor authentication")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't enable two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	err = qtx.EnableTOTPCredential(r.Context(), database.EnableTOTPCredentialParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't enable two-factor authentication")
+		return
+	}
+	err = qtx.DeleteRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't enable two-factor authentication")
+		return
+	}
+	for _, code := range codes {
+		err = qtx.CreateRecoveryCode(r.Context(), database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashRecoveryCode(code),
+		})
+		if err != nil {
+			respondWithError(w, 500, "Couldn't enable two-factor authentication")
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't enable two-factor authentication")
+		return
+	}
+	respondWithJSON(w, 200, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: codes,
+	})
+}
+
+// handlerDisableMFA turns 2FA off. A stolen access token alone isn't enough:
+// the request needs a current code that hasn't been used yet. Recovery codes
+// are not accepted here.
+func (cfg *apiConfig) handlerDisableMFA(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Code string `json:"code"`
+	}
+	userID := auth.UserIDFromContext(r.Context())
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	cred, err := cfg.dbQueries.GetTOTPCredential(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !cred.EnabledAt.Valid) {
+		respondWithError(w, 400, "Two-factor authentication is not enabled")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	throttleKeys := []throttle.Key{throttle.AccountKey(dbUser.Email), throttle.IPKey(cfg.clientIP(r))}
+	wait, err := cfg.loginLimiter.Check(r.Context(), throttleKeys...)
+	if err != nil {
+		respondWithError(w, 500, "Error checking login attempts")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+	ok, err := cfg.checkTOTP(r.Context(), cred, rBody.Code)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	if !ok {
+		err = cfg.loginLimiter.Fail(r.Context(), throttleKeys...)
+		if err != nil {
+			log.Printf("Error recording failed code: %v", err)
+		}
+		respondWithError(w, 401, "Invalid code")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	err = qtx.DeleteTOTPCredential(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	err = qtx.DeleteRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't disable two-factor authentication")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// handlerLoginMFA is the second step of a login with 2FA: it trades the
+// challenge token from POST /api/login and either a TOTP code or an unused
+// recovery code for access and refresh tokens.
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	challenge, err := auth.ParseMFAChallenge(rBody.MFAToken, cfg.jwtKeys)
+	if err != nil {
+		respondWithError(w, 401, "Invalid or expired MFA token")
+		return
+	}
+	userID, err := uuid.Parse(challenge.Subject)
+	if err != nil {
+		respondWithError(w, 401, "Invalid or expired MFA token")
+		return
+	}
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 401, "Invalid or expired MFA token")
+		return
+	}
+	if dbUser.SuspendedAt.Valid {
+		respondWithError(w, 403, "Account suspended")
+		return
+	}
+	cred, err := cfg.dbQueries.GetTOTPCredential(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !cred.EnabledAt.Valid) {
+		respondWithError(w, 401, "Invalid or expired MFA token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify code")
+		return
+	}
+	throttleKeys := []throttle.Key{throttle.AccountKey(dbUser.Email), throttle.IPKey(cfg.clientIP(r))}
+	wait, err := cfg.loginLimiter.Check(r.Context(), throttleKeys...)
+	if err != nil {
+		respondWithError(w, 500, "Error checking login attempts")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+	ok := false
+	switch {
+	case rBody.Code != "":
+		ok, err = cfg.checkTOTP(r.Context(), cred, rBody.Code)
+	case rBody.RecoveryCode != "":
+		var used int64
+		used, err = cfg.dbQueries.UseRecoveryCode(r.Context(), database.UseRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashRecoveryCode(rBody.RecoveryCode),
+		})
+		ok = used == 1
+	default:
+		respondWithError(w, 400, "A code or recovery code is required")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify code")
+		return
+	}
+	if !ok {
+		err = cfg.loginLimiter.Fail(r.Context(), throttleKeys...)
+		if err != nil {
+			log.Printf("Error recording failed code: %v", err)
+		}
+		respondWithError(w, 401, "Invalid code")
+		return
+	}
+	// The challenge is only burned once a code checks out, so a typo can be
+	// retried, but a captured challenge can't mint a second session.
+	used, err := cfg.dbQueries.UseMFAChallenge(r.Context(), database.UseMFAChallengeParams{
+		Jti:       challenge.ID,
+		ExpiresAt: challenge.ExpiresAt.Time,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't verify code")
+		return
+	}
+	if used == 0 {
+		respondWithError(w, 401, "Invalid or expired MFA token")
+		return
+	}
+	err = cfg.loginLimiter.Succeed(r.Context(), throttleKeys[0])
+	if err != nil {
+		log.Printf("Error clearing failed logins: %v", err)
+	}
+	cfg.respondWithNewSession(w, r, dbUser.ID, dbUser.Role, dbUser.TokenVersion)
+}
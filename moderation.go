@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const (
+	chirpPublished = "published"
+	chirpHeld      = "held"
+	chirpHidden    = "hidden"
+)
+
+// moderationStatus is the status a chirp is stored with after moderation.
+// Held chirps are only visible to their author and admins until they are
+// reviewed.
+func moderationStatus(result moderation.Result) string {
+	if result.Action == moderation.ActionHold {
+		return chirpHeld
+	}
+	return chirpPublished
+}
+
+// canViewChirp reports whether viewerID may see rawChirp. Published chirps
+// are public; held and hidden chirps are only visible to their author and
+// to viewers allowed to see hidden chirps.
+func canViewChirp(rawChirp database.Chirp, viewerID uuid.UUID, seeHidden bool) bool {
+	return rawChirp.ModerationStatus == chirpPublished || rawChirp.UserID == viewerID || seeHidden
+}
+
+// visibleChirps drops the chirps viewerID may not see, keeping the order.
+func visibleChirps(rawChirps []database.Chirp, viewerID uuid.UUID, seeHidden bool) []database.Chirp {
+	visible := []database.Chirp{}
+	for _, rawChirp := range rawChirps {
+		if canViewChirp(rawChirp, viewerID, seeHidden) {
+			visible = append(visible, rawChirp)
+		}
+	}
+	return visible
+}
+
+// nullViewerID is viewerID as a query parameter, NULL for anonymous requests.
+func nullViewerID(viewerID uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: viewerID, Valid: viewerID != uuid.Nil}
+}
+
+func (cfg *apiConfig) handlerGetModerationRules(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, 200, cfg.moderator.Rules())
+}
+
+// handlerPutModerationRule adds a rule, or replaces the rule with the same
+// ID. The change takes effect immediately and is saved to the config file.
+func (cfg *apiConfig) handlerPutModerationRule(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rule := moderation.Rule{}
+	err := decoder.Decode(&rule)
+	if err != nil {
+		respondWithError(w, 400, "Invalid request body")
+		return
+	}
+	err = cfg.moderator.PutRule(rule)
+	if err != nil {
+		respondWithError(w, 400, "Invalid rule")
+		return
+	}
+	respondWithJSON(w, 200, rule)
+}
+
+func (cfg *apiConfig) handlerDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	err := cfg.moderator.DeleteRule(r.PathValue("id"))
+	if errors.Is(err, moderation.ErrRuleNotFound) {
+		respondWithError(w, 404, "Rule not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't delete rule")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// handlerReloadModerationRules re-reads the config file, picking up edits
+// made to it by hand.
+func (cfg *apiConfig) handlerReloadModerationRules(w http.ResponseWriter, r *http.Request) {
+	err := cfg.moderator.Reload()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reload rules")
+		return
+	}
+	respondWithJSON(w, 200, cfg.moderator.Rules())
+}
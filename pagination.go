@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageParams holds the parsed limit and cursor query parameters shared by
+// every paginated listing endpoint.
+type pageParams struct {
+	limit           int32
+	cursorCreatedAt time.Time
+	cursorID        uuid.UUID
+	hasCursor       bool
+}
+
+func parsePageParams(query url.Values) (pageParams, error) {
+	limit, err := parseLimitParam(query)
+	if err != nil {
+		return pageParams{}, err
+	}
+	page := pageParams{limit: limit}
+	if cursor := query.Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return pageParams{}, errors.New("invalid cursor")
+		}
+		page.cursorCreatedAt = createdAt
+		page.cursorID = id
+		page.hasCursor = true
+	}
+	return page, nil
+}
+
+func parseLimitParam(query url.Values) (int32, error) {
+	limit := query.Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > maxPageLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	return int32(n), nil
+}
+
+// parseSortParam reports whether the sort query parameter asks for
+// newest-first ordering.
+func parseSortParam(query url.Values) (bool, error) {
+	switch query.Get("sort") {
+	case "", "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, errors.New("sort must be asc or desc")
+	}
+}
+
+// fetchLimit is the number of rows to request from the database: one more
+// than the page size so we can tell whether another page follows.
+func (p pageParams) fetchLimit() int32 {
+	return p.limit + 1
+}
+
+func (p pageParams) nullCreatedAt() sql.NullTime {
+	return sql.NullTime{Time: p.cursorCreatedAt, Valid: p.hasCursor}
+}
+
+func (p pageParams) nullID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: p.cursorID, Valid: p.hasCursor}
+}
+
+// trimPage drops the extra row requested by fetchLimit and, when there was
+// one, sets the Link header pointing at the following page.
+func trimPage[T any](w http.ResponseWriter, r *http.Request, page pageParams, items []T, position func(T) (time.Time, uuid.UUID)) []T {
+	if len(items) <= int(page.limit) {
+		return items
+	}
+	items = items[:page.limit]
+	setNextLink(w, r, encodeCursor(position(items[len(items)-1])))
+	return items
+}
+
+// encodeCursor packs the (created_at, id) position of the last row on a page
+// into an opaque, URL-safe token.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	ts, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return createdAt, id, nil
+}
+
+// setNextLink advertises the next page with an RFC 8288 Link header that
+// repeats the current request with the cursor replaced.
+func setNextLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/mailer"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
+)
+
+const (
+	passwordResetLifetime = time.Hour
+	// passwordResetCooldown limits how often reset mail can be sent to one
+	// account, so the endpoint can't be used to flood someone's inbox.
+	passwordResetCooldown = time.Minute
+)
+
+// handlerRequestPasswordReset emails a reset link. It answers the same way
+// whether or not the email belongs to an account, and does the work on the
+// mail queue after responding so that timing doesn't give the answer away
+// either. Requests are throttled per IP, since the per-account cooldown
+// alone lets one client spray reset mail across many accounts.
+func (cfg *apiConfig) handlerRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Email string `json:"email"`
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	throttleKey := throttle.ResetIPKey(cfg.clientIP(r))
+	wait, err := cfg.loginLimiter.Check(r.Context(), throttleKey)
+	if err != nil {
+		respondWithError(w, 500, "Error checking password reset requests")
+		return
+	}
+	if wait > 0 {
+		respondTooManyRequests(w, wait, "Too many password reset requests")
+		return
+	}
+	err = cfg.loginLimiter.Fail(r.Context(), throttleKey)
+	if err != nil {
+		log.Printf("Error recording password reset request: %v", err)
+	}
+	email := rBody.Email
+	err = cfg.mailQueue.Enqueue(func(ctx context.Context) {
+		err := cfg.sendPasswordReset(ctx, email)
+		if err != nil {
+			log.Printf("Error sending password reset: %v", err)
+		}
+	})
+	if err != nil {
+		log.Printf("Error queueing password reset: %v", err)
+	}
+	respondWithJSON(w, 202, struct {
+		Message string `json:"message"`
+	}{
+		Message: "If an account exists for that email, a reset link has been sent",
+	})
+}
+
+func (cfg *apiConfig) sendPasswordReset(ctx context.Context, email string) error {
+	email, err := mailer.NormalizeAddress(email)
+	if err != nil {
+		return nil
+	}
+	dbUser, err := cfg.dbQueries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dbUser.SuspendedAt.Valid {
+		return nil
+	}
+	recent, err := cfg.dbQueries.HasRecentPasswordResetToken(ctx, database.HasRecentPasswordResetTokenParams{
+		UserID:          dbUser.ID,
+		CooldownSeconds: passwordResetCooldown.Seconds(),
+	})
+	if err != nil || recent {
+		return err
+	}
+	token, err := auth.MakeEmailToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.dbQueries.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash:       auth.HashEmailToken(token),
+		UserID:          dbUser.ID,
+		LifetimeSeconds: passwordResetLifetime.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+	link := cfg.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      dbUser.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account.\n\n"+
+			"To choose a new password, open this link within the next hour:\n\n%s\n\n"+
+			"If this wasn't you, you can ignore this email.\n", link),
+	})
+}
+
+// handlerConfirmPasswordReset sets a new password using a token from a reset
+// email. Every outstanding reset token for the account is used up, and all
+// of its sessions and access tokens are revoked.
+func (cfg *apiConfig) handlerConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := requestBody{}
+	err := decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Error decoding request body")
+		return
+	}
+	if rBody.Password == "" {
+		respondWithError(w, 400, "Password is required")
+		return
+	}
+	hashed, err := auth.HashPassword(rBody.Password)
+	if err != nil {
+		respondWithError(w, 400, "Error hashing password")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	stored, err := qtx.GetPasswordResetTokenForUpdate(r.Context(), auth.HashEmailToken(rBody.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "Invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	dbUser, err := qtx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+		ID:       stored.UserID,
+		Password: hashed,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	err = qtx.UsePasswordResetTokens(r.Context(), stored.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	err = qtx.RevokeUserRefreshTokens(r.Context(), stored.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't reset password")
+		return
+	}
+	cfg.tokenVersions.Invalidate(stored.UserID)
+	// Whoever holds the reset link controls the inbox, so a lockout from
+	// guesses at the old password shouldn't keep them out.
+	err = cfg.loginLimiter.Clear(r.Context(), throttle.AccountKey(dbUser.Email))
+	if err != nil {
+		log.Printf("Error clearing login lockout: %v", err)
+	}
+	w.WriteHeader(204)
+}
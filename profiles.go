@@ -0,0 +1,83 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/chirptext"
+	"github.com/google/uuid"
+)
+
+// profile is the public view of a user. It never includes the email
+// address or anything else from account that only the user should see.
+type profile struct {
+	ID             uuid.UUID `json:"id"`
+	Username       string    `json:"username,omitempty"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	JoinedAt       time.Time `json:"joined_at"`
+	ChirpyRed      bool      `json:"chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	ChirpCount     int64     `json:"chirp_count"`
+}
+
+// handlerGetProfile looks a user up by ID or, failing that, by username.
+// Users who blocked the caller appear not to exist.
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+	idOrUsername := r.PathValue("id_or_username")
+	userID, err := uuid.Parse(idOrUsername)
+	if err != nil {
+		if !chirptext.ValidUsername(idOrUsername) {
+			respondWithError(w, 404, "User not found")
+			return
+		}
+		userID, err = cfg.dbQueries.GetUserIDByUsername(r.Context(), idOrUsername)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 404, "User not found")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 500, "Couldn't get profile")
+			return
+		}
+	}
+	filter, err := cfg.chirpFilterFor(r.Context(), auth.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get profile")
+		return
+	}
+	if !filter.canSee(userID) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	row, err := cfg.dbQueries.GetUserProfile(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get profile")
+		return
+	}
+	respondWithJSON(w, 200, profile{
+		ID:             row.ID,
+		Username:       row.Username.String,
+		DisplayName:    row.DisplayName,
+		Bio:            row.Bio,
+		AvatarURL:      row.AvatarUrl,
+		Location:       row.Location,
+		Website:        row.Website,
+		JoinedAt:       row.CreatedAt,
+		ChirpyRed:      row.IsChirpyRed,
+		FollowerCount:  row.FollowerCount,
+		FollowingCount: row.FollowingCount,
+		ChirpCount:     row.ChirpCount,
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+type rechirpInfo struct {
+	UserID      uuid.UUID `json:"user_id"`
+	RechirpedAt time.Time `json:"rechirped_at"`
+}
+
+// feedEntry is one row of a feed that mixes original chirps with rechirps.
+// RechirpedBy is set when the entry is a rechirp, and ActivityAt is then the
+// time of the rechirp rather than of the original chirp.
+type feedEntry struct {
+	ChirpID     uuid.UUID
+	RechirpedBy uuid.NullUUID
+	ActivityAt  time.Time
+}
+
+func feedEntryPosition(e feedEntry) (time.Time, uuid.UUID) {
+	return e.ActivityAt, e.ChirpID
+}
+
+// buildFeed loads and builds the chirps behind a page of feed entries,
+// keeping the feed order and marking rechirps with who reposted them.
+func (cfg *apiConfig) buildFeed(ctx context.Context, entries []feedEntry, viewerID uuid.UUID) ([]chirp, error) {
+	ids := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, e := range entries {
+		if !seen[e.ChirpID] {
+			seen[e.ChirpID] = true
+			ids = append(ids, e.ChirpID)
+		}
+	}
+	rawChirps, err := cfg.chirpsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	built, err := cfg.buildChirps(ctx, rawChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uuid.UUID]chirp{}
+	for _, c := range built {
+		byID[c.ID] = c
+	}
+	feed := []chirp{}
+	for _, e := range entries {
+		c, ok := byID[e.ChirpID]
+		if !ok {
+			continue
+		}
+		if e.RechirpedBy.Valid {
+			c.RechirpedBy = &rechirpInfo{UserID: e.RechirpedBy.UUID, RechirpedAt: e.ActivityAt}
+		}
+		feed = append(feed, c)
+	}
+	return feed, nil
+}
+
+// respondWithAuthorFeed serves GET /api/chirps?author_id=, which lists the
+// author's own chirps interleaved with the chirps they rechirped.
+func (cfg *apiConfig) respondWithAuthorFeed(w http.ResponseWriter, r *http.Request, authorID uuid.UUID, page pageParams, desc bool) {
+	viewerID := auth.UserIDFromContext(r.Context())
+	seeHidden := hasPermission(r, auth.PermViewHiddenChirps)
+	entries := []feedEntry{}
+	if desc {
+		rows, err := cfg.dbQueries.ListAuthorFeedDesc(r.Context(), database.ListAuthorFeedDescParams{
+			AuthorID:        authorID,
+			ViewerID:        nullViewerID(viewerID),
+			IncludeHidden:   seeHidden,
+			CursorCreatedAt: page.nullCreatedAt(),
+			CursorID:        page.nullID(),
+			PageLimit:       page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(w, 500, "Couldn't get chirps")
+			return
+		}
+		for _, row := range rows {
+			entries = append(entries, feedEntry(row))
+		}
+	} else {
+		rows, err := cfg.dbQueries.ListAuthorFeedAsc(r.Context(), database.ListAuthorFeedAscParams{
+			AuthorID:        authorID,
+			ViewerID:        nullViewerID(viewerID),
+			IncludeHidden:   seeHidden,
+			CursorCreatedAt: page.nullCreatedAt(),
+			CursorID:        page.nullID(),
+			PageLimit:       page.fetchLimit(),
+		})
+		if err != nil {
+			respondWithError(w, 500, "Couldn't get chirps")
+			return
+		}
+		for _, row := range rows {
+			entries = append(entries, feedEntry(row))
+		}
+	}
+	entries = trimPage(w, r, page, entries, feedEntryPosition)
+	chirps, err := cfg.buildFeed(r.Context(), entries, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	respondWithJSON(w, 200, chirps)
+}
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rawChirp, err := cfg.visibleChirp(r.Context(), chirpID, userID, false)
+	if err != nil || rawChirp.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	err = cfg.dbQueries.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't rechirp")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	err = cfg.dbQueries.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't undo rechirp")
+		return
+	}
+	w.WriteHeader(204)
+}
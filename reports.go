@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxReportReasonLength = 500
+
+type report struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	ReporterID uuid.UUID `json:"reporter_id"`
+	Reason     string    `json:"reason"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// moderationItem is an entry in the review queue: a chirp that was held by
+// a moderation rule or has pending reports against it.
+type moderationItem struct {
+	Chirp            chirp    `json:"chirp"`
+	ModerationStatus string   `json:"moderation_status"`
+	ModerationRules  []string `json:"moderation_rules"`
+	Reports          []report `json:"reports"`
+}
+
+func reportFromDB(row database.Report) report {
+	return report{
+		ID:         row.ID,
+		ChirpID:    row.ChirpID,
+		ReporterID: row.ReporterID,
+		Reason:     row.Reason,
+		Status:     row.Status,
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Reason string `json:"reason"`
+	}
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := request{}
+	err = decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Invalid request body")
+		return
+	}
+	reason := strings.TrimSpace(rBody.Reason)
+	if reason == "" {
+		respondWithError(w, 400, "A reason is required")
+		return
+	}
+	if len(reason) > maxReportReasonLength {
+		respondWithError(w, 400, "Reason is too long")
+		return
+	}
+	rawChirp, err := cfg.visibleChirp(r.Context(), chirpID, userID, false)
+	if err != nil || rawChirp.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if rawChirp.UserID == userID {
+		respondWithError(w, 400, "You can't report your own chirp")
+		return
+	}
+	row, err := cfg.dbQueries.CreateReport(r.Context(), database.CreateReportParams{
+		ChirpID:    chirpID,
+		ReporterID: userID,
+		Reason:     reason,
+	})
+	if isUniqueViolation(err, "reports_chirp_id_reporter_id_key") {
+		respondWithError(w, 409, "You have already reported this chirp")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't report chirp")
+		return
+	}
+	respondWithJSON(w, 201, reportFromDB(row))
+}
+
+// handlerGetModerationQueue lists chirps waiting for review, oldest first,
+// each with its pending reports.
+func (cfg *apiConfig) handlerGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	rawChirps, err := cfg.dbQueries.ListModerationQueue(r.Context(), database.ListModerationQueueParams{
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get moderation queue")
+		return
+	}
+	rawChirps = trimPage(w, r, page, rawChirps, chirpPosition)
+	ids := []uuid.UUID{}
+	for _, rawChirp := range rawChirps {
+		ids = append(ids, rawChirp.ID)
+	}
+	rows, err := cfg.dbQueries.ListPendingReports(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get moderation queue")
+		return
+	}
+	reports := map[uuid.UUID][]report{}
+	for _, row := range rows {
+		reports[row.ChirpID] = append(reports[row.ChirpID], reportFromDB(row))
+	}
+	byID := map[uuid.UUID]database.Chirp{}
+	for _, rawChirp := range rawChirps {
+		byID[rawChirp.ID] = rawChirp
+	}
+	// The queue skips the moderator's blocks and mutes, so an author can't
+	// keep their chirps out of review by blocking the moderators.
+	chirps, err := cfg.assembleChirps(r.Context(), rawChirps, chirpFilter{viewerID: auth.UserIDFromContext(r.Context())})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	items := []moderationItem{}
+	for _, c := range chirps {
+		item := moderationItem{
+			Chirp:            c,
+			ModerationStatus: byID[c.ID].ModerationStatus,
+			ModerationRules:  byID[c.ID].ModerationRules,
+			Reports:          reports[c.ID],
+		}
+		if item.Reports == nil {
+			item.Reports = []report{}
+		}
+		items = append(items, item)
+	}
+	respondWithJSON(w, 200, items)
+}
+
+// handlerResolveChirp closes every pending report on a chirp. "dismiss"
+// leaves the chirp up (publishing it if it was held), "hide" keeps it from
+// everyone but its author and admins, and "delete" tombstones it so that the
+// reports and any replies keep pointing at it. suspend_author also suspends
+// the chirp's author.
+func (cfg *apiConfig) handlerResolveChirp(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Action        string `json:"action"`
+		SuspendAuthor bool   `json:"suspend_author"`
+	}
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := request{}
+	err = decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Invalid request body")
+		return
+	}
+	var reportStatus string
+	switch rBody.Action {
+	case "dismiss":
+		reportStatus = "dismissed"
+	case "hide":
+		reportStatus = "hidden"
+	case "delete":
+		reportStatus = "deleted"
+	default:
+		respondWithError(w, 400, "Action must be dismiss, hide or delete")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't resolve reports")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	rawChirp, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil || rawChirp.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	switch rBody.Action {
+	case "dismiss":
+		err = qtx.SetChirpModerationStatus(r.Context(), database.SetChirpModerationStatusParams{
+			ID:               chirpID,
+			ModerationStatus: chirpPublished,
+		})
+	case "hide":
+		err = qtx.SetChirpModerationStatus(r.Context(), database.SetChirpModerationStatusParams{
+			ID:               chirpID,
+			ModerationStatus: chirpHidden,
+		})
+	case "delete":
+		err = qtx.TombstoneChirp(r.Context(), chirpID)
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't resolve reports")
+		return
+	}
+	err = qtx.ResolveReports(r.Context(), database.ResolveReportsParams{
+		ChirpID:    chirpID,
+		Status:     reportStatus,
+		ResolvedBy: nullViewerID(auth.UserIDFromContext(r.Context())),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't resolve reports")
+		return
+	}
+	if rBody.SuspendAuthor {
+		err = suspendUser(r, qtx, rawChirp.UserID)
+		if err != nil {
+			respondWithError(w, 500, "Couldn't suspend author")
+			return
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't resolve reports")
+		return
+	}
+	if rBody.SuspendAuthor {
+		cfg.tokenVersions.Invalidate(rawChirp.UserID)
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	_, err = cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't suspend user")
+		return
+	}
+	defer tx.Rollback()
+	err = suspendUser(r, cfg.dbQueries.WithTx(tx), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't suspend user")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't suspend user")
+		return
+	}
+	cfg.tokenVersions.Invalidate(userID)
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) handlerUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	err = cfg.dbQueries.UnsuspendUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't unsuspend user")
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// suspendUser marks the user suspended, which also bumps their token version
+// and so revokes their access tokens, and revokes their refresh tokens so
+// that they can't sign in again or renew an access token. Callers must
+// invalidate the user's cached token version once the transaction commits.
+func suspendUser(r *http.Request, qtx *database.Queries, userID uuid.UUID) error {
+	err := qtx.SuspendUser(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return qtx.RevokeUserRefreshTokens(r.Context(), userID)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/moderation"
+	"github.com/google/uuid"
+)
+
+type chirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Body string `json:"body"`
+	}
+	userID := auth.UserIDFromContext(r.Context())
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := request{}
+	err = decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Invalid request body")
+		return
+	}
+	if len(rBody.Body) > 140 {
+		respondWithError(w, 400, "Chirp is too long")
+		return
+	}
+	dbUser, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 401, "Invalid token")
+		return
+	}
+	editWindow := cfg.editWindow
+	if dbUser.IsChirpyRed {
+		editWindow = cfg.redEditWindow
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	current, err := qtx.GetChirpForUpdate(r.Context(), chirpID)
+	if err != nil || current.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if current.UserID != userID {
+		respondWithError(w, 403, "You are not allowed to edit this chirp")
+		return
+	}
+	// created_at is stored without a zone, so the window is measured in
+	// the database, against the same clock that wrote it.
+	editable, err := qtx.IsChirpEditable(r.Context(), database.IsChirpEditableParams{
+		EditWindowSeconds: editWindow.Seconds(),
+		ID:                current.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	if !editable {
+		respondWithError(w, 403, "The edit window for this chirp has closed")
+		return
+	}
+	err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   current.ID,
+		Body:      current.Body,
+		CreatedAt: current.UpdatedAt,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't save revision")
+		return
+	}
+	result := cfg.moderator.Moderate(rBody.Body)
+	if result.Action == moderation.ActionReject {
+		respondWithError(w, 400, "Chirp violates content rules")
+		return
+	}
+	// A chirp hidden by a moderator stays hidden; editing it can't undo the
+	// decision.
+	rawChirp, err := qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		ID:               current.ID,
+		Body:             result.Text,
+		ModerationStatus: moderationStatus(result),
+		ModerationRules:  result.Rules,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	err = qtx.ClearChirpHashtags(r.Context(), rawChirp.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	err = qtx.ClearMentions(r.Context(), rawChirp.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	err = saveChirpEntities(r.Context(), qtx, rawChirp.ID, result.Text)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't save hashtags and mentions")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update chirp")
+		return
+	}
+	c, err := cfg.buildChirp(r.Context(), rawChirp, userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirp")
+		return
+	}
+	respondWithJSON(w, 200, c)
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rawChirp, err := cfg.visibleChirp(r.Context(), chirpID, auth.UserIDFromContext(r.Context()), hasPermission(r, auth.PermViewHiddenChirps))
+	if err != nil || rawChirp.DeletedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rows, err := cfg.dbQueries.ListChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get revisions")
+		return
+	}
+	revisions := []chirpRevision{}
+	for _, row := range rows {
+		revisions = append(revisions, chirpRevision{
+			ID:         row.ID,
+			ChirpID:    row.ChirpID,
+			Body:       row.Body,
+			CreatedAt:  row.CreatedAt,
+			ReplacedAt: row.ReplacedAt,
+		})
+	}
+	respondWithJSON(w, 200, revisions)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/mailer"
+	"github.com/google/uuid"
+)
+
+// hasPermission reports whether the authenticated caller's role grants perm.
+// Anonymous callers have no permissions.
+func hasPermission(r *http.Request, perm auth.Permission) bool {
+	p, _ := auth.PrincipalFromContext(r.Context())
+	return p.Can(perm)
+}
+
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Role string `json:"role"`
+	}
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	// Admins can't demote themselves, so there is always at least one.
+	if userID == auth.UserIDFromContext(r.Context()) {
+		respondWithError(w, 400, "You can't change your own role")
+		return
+	}
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := request{}
+	err = decoder.Decode(&rBody)
+	if err != nil {
+		respondWithError(w, 400, "Invalid request body")
+		return
+	}
+	role, err := auth.ParseRole(rBody.Role)
+	if err != nil {
+		respondWithError(w, 400, "Role must be user, moderator or admin")
+		return
+	}
+	updated, err := cfg.dbQueries.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: string(role),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update role")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	// The role is baked into access tokens; bumping the version makes the
+	// user refresh to pick up the new one.
+	cfg.tokenVersions.Invalidate(userID)
+	acct, err := cfg.accountFor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't update role")
+		return
+	}
+	respondWithJSON(w, 200, acct)
+}
+
+// bootstrapAdmin promotes the user with the given email to admin. It only
+// works while there are no admins; after that, admins manage roles through
+// the API.
+func bootstrapAdmin(ctx context.Context, dbQueries *database.Queries, email string) error {
+	email, err := mailer.NormalizeAddress(email)
+	if err != nil {
+		return err
+	}
+	_, err = dbQueries.PromoteFirstAdmin(ctx, email)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	_, err = dbQueries.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no user with email %s", email)
+	}
+	if err != nil {
+		return err
+	}
+	return errors.New("an admin already exists")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+type searchResult struct {
+	chirp
+	Rank float64 `json:"rank"`
+	// Snippet is HTML: the body is escaped before the matches are wrapped
+	// in <mark>, so it is safe to render as markup.
+	Snippet string `json:"snippet"`
+}
+
+// handlerSearchChirps runs a PostgreSQL full-text search over chirp bodies.
+// q uses web search syntax: "quoted phrases", OR, and -excluded words.
+// Results are ordered by rank, so the cursor encodes (rank, id) rather than
+// the (created_at, id) used by the time-ordered listings.
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		respondWithError(w, 400, "Missing search query")
+		return
+	}
+	authorID, err := parseAuthorIDParam(query)
+	if err != nil {
+		respondWithError(w, 400, "Invalid author_id")
+		return
+	}
+	limit, err := parseLimitParam(query)
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	cursorRank := sql.NullFloat64{}
+	cursorID := uuid.NullUUID{}
+	if cursor := query.Get("cursor"); cursor != "" {
+		rank, id, err := decodeRankCursor(cursor)
+		if err != nil {
+			respondWithError(w, 400, "invalid cursor")
+			return
+		}
+		cursorRank = sql.NullFloat64{Float64: rank, Valid: true}
+		cursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	viewerID := auth.UserIDFromContext(r.Context())
+	rows, err := cfg.dbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:      q,
+		AuthorID:   authorID,
+		ViewerID:   nullViewerID(viewerID),
+		CursorRank: cursorRank,
+		CursorID:   cursorID,
+		PageLimit:  limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't search chirps")
+		return
+	}
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		setNextLink(w, r, encodeRankCursor(last.Rank, last.ID))
+	}
+	ids := []uuid.UUID{}
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	rawChirps, err := cfg.chirpsByIDs(r.Context(), ids)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't search chirps")
+		return
+	}
+	chirps, err := cfg.buildChirps(r.Context(), rawChirps, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	byID := map[uuid.UUID]chirp{}
+	for _, c := range chirps {
+		byID[c.ID] = c
+	}
+	results := []searchResult{}
+	for _, row := range rows {
+		c, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, searchResult{chirp: c, Rank: row.Rank, Snippet: row.Snippet})
+	}
+	respondWithJSON(w, 200, results)
+}
+
+func encodeRankCursor(rank float64, id uuid.UUID) string {
+	raw := strconv.FormatFloat(rank, 'g', -1, 64) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeRankCursor(cursor string) (float64, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	rawRank, rawID, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return 0, uuid.Nil, errors.New("malformed cursor")
+	}
+	rank, err := strconv.ParseFloat(rawRank, 64)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return 0, uuid.Nil, err
+	}
+	return rank, id, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxUserAgentLength = 512
+
+// session is a login: the family of refresh tokens descended from one call
+// to POST /api/login.
+type session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+}
+
+// clientIP returns the address the request came from. X-Forwarded-For is
+// only trusted when the server is configured to run behind a proxy, since
+// clients can set it to anything.
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userAgent returns the request's User-Agent, cut to at most
+// maxUserAgentLength bytes without splitting a character. Postgres rejects
+// invalid UTF-8, so any in the header is replaced.
+func userAgent(r *http.Request) string {
+	ua := strings.ToValidUTF8(r.UserAgent(), "\uFFFD")
+	if len(ua) > maxUserAgentLength {
+		cut := maxUserAgentLength
+		for cut > 0 && !utf8.RuneStart(ua[cut]) {
+			cut--
+		}
+		ua = ua[:cut]
+	}
+	return ua
+}
+
+// sessionRevokedFunc looks up whether a session has been logged out for the
+// authenticator. A session that no longer exists counts as logged out.
+func sessionRevokedFunc(db *database.Queries) auth.SessionRevokedFunc {
+	return func(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+		revoked, err := db.IsTokenFamilyRevoked(ctx, sessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return revoked, err
+	}
+}
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	families, err := cfg.dbQueries.ListActiveTokenFamilies(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get sessions")
+		return
+	}
+	sessions := []session{}
+	for _, family := range families {
+		sessions = append(sessions, sessionFromDB(family, principal.SessionID))
+	}
+	respondWithJSON(w, 200, sessions)
+}
+
+// handlerRevokeSession logs out one of the caller's sessions without needing
+// its refresh token, e.g. for a lost phone. Access tokens already issued to
+// that session stop working too.
+func (cfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := auth.UserIDFromContext(r.Context())
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+	family, err := cfg.dbQueries.GetTokenFamily(r.Context(), sessionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && family.UserID != userID) {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Couldn't revoke session")
+		return
+	}
+	err = cfg.dbQueries.RevokeTokenFamily(r.Context(), family.ID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't revoke session")
+		return
+	}
+	cfg.revokedSessions.Invalidate(family.ID)
+	w.WriteHeader(204)
+}
+
+// handlerRevokeOtherSessions logs out everywhere except the session the
+// request's access token belongs to.
+func (cfg *apiConfig) handlerRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	families, err := cfg.dbQueries.ListActiveTokenFamilies(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't revoke sessions")
+		return
+	}
+	err = cfg.dbQueries.RevokeOtherTokenFamilies(r.Context(), database.RevokeOtherTokenFamiliesParams{
+		UserID: principal.UserID,
+		KeepID: principal.SessionID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't revoke sessions")
+		return
+	}
+	for _, family := range families {
+		cfg.revokedSessions.Invalidate(family.ID)
+	}
+	w.WriteHeader(204)
+}
+
+func sessionFromDB(family database.TokenFamily, currentID uuid.UUID) session {
+	return session{
+		ID:         family.ID,
+		CreatedAt:  family.CreatedAt,
+		LastUsedAt: family.LastUsedAt,
+		UserAgent:  family.UserAgent,
+		IPAddress:  family.IpAddress,
+		Current:    family.ID == currentID,
+	}
+}
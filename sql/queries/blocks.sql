@@ -0,0 +1,52 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: ListBlocks :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, blocked_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_a)::uuid AND blocked_id = sqlc.arg(user_b)::uuid)
+    OR (blocker_id = sqlc.arg(user_b)::uuid AND blocked_id = sqlc.arg(user_a)::uuid)
+);
+
+-- name: ListBlockerIDs :many
+SELECT blocker_id FROM blocks
+WHERE blocked_id = $1;
+
+-- name: RemoveFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_a)::uuid AND followee_id = sqlc.arg(user_b)::uuid)
+OR (follower_id = sqlc.arg(user_b)::uuid AND followee_id = sqlc.arg(user_a)::uuid);
+
+-- name: MuteUser :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListMutes :many
+SELECT muted_id AS user_id, created_at FROM mutes
+WHERE muter_id = sqlc.arg(user_id)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, muted_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListFilteredUserIDs :many
+SELECT blocked_id AS user_id FROM blocks WHERE blocker_id = sqlc.arg(user_id)
+UNION
+SELECT muted_id FROM mutes WHERE muter_id = sqlc.arg(user_id);
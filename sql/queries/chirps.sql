@@ -1,21 +1,340 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (gen_random_uuid(), now(), now(), $1, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, moderation_status, moderation_rules)
+VALUES (gen_random_uuid(), now(), now(), $1, $2, $3, $4, $5, $6)
 RETURNING *;
 
--- name: GetChirps :many
+-- name: ListChirpsAsc :many
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE deleted_at IS NULL AND (moderation_status = 'published' OR user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+    OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL AND (moderation_status = 'published' OR user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+    OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: SearchChirps :many
+SELECT ranked.id, ranked.rank,
+    ts_headline('english', replace(replace(replace(replace(replace(ranked.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'), websearch_to_tsquery('english', sqlc.arg(query)::text), 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15')::text AS snippet
+FROM (
+    SELECT id, body, ts_rank(to_tsvector('english', body), websearch_to_tsquery('english', sqlc.arg(query)::text))::float8 AS rank
+    FROM chirps
+    WHERE deleted_at IS NULL AND moderation_status = 'published'
+    AND to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+    )
+) ranked
+WHERE sqlc.narg(cursor_rank)::float8 IS NULL OR (ranked.rank, ranked.id) < (sqlc.narg(cursor_rank), sqlc.narg(cursor_id)::uuid)
+ORDER BY ranked.rank DESC, ranked.id DESC
+LIMIT sqlc.arg(page_limit);
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+);
+
+-- name: GetActiveChirpByID :one
+SELECT * FROM chirps
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+);
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: IsChirpEditable :one
+SELECT created_at > NOW() - make_interval(secs => sqlc.arg(edit_window_seconds)::float8) AS editable
+FROM chirps
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+    moderation_status = CASE WHEN moderation_status = 'hidden' THEN 'hidden' ELSE $3 END,
+    moderation_rules = $4, updated_at = now(), edited_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetChirpModerationStatus :exec
+UPDATE chirps
+SET moderation_status = $2, updated_at = now()
+WHERE id = $1;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1;
 
--- name: GetChirpsByUserID :many
-SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
\ No newline at end of file
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', deleted_at = now(), updated_at = now()
+WHERE id = $1;
+
+-- name: HasReplies :one
+SELECT EXISTS (
+    SELECT 1 FROM chirps WHERE in_reply_to = sqlc.arg(chirp_id)::uuid
+);
+
+-- name: CountReplies :many
+SELECT in_reply_to::uuid AS chirp_id, count(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::uuid[]) AND deleted_at IS NULL
+GROUP BY in_reply_to;
+
+-- name: CountQuotes :many
+SELECT quote_of::uuid AS chirp_id, count(*) AS quote_count FROM chirps
+WHERE quote_of = ANY(sqlc.arg(chirp_ids)::uuid[]) AND deleted_at IS NULL AND moderation_status = 'published'
+GROUP BY quote_of;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, depth) AS (
+    SELECT in_reply_to, 1 FROM chirps
+    WHERE chirps.id = sqlc.arg(id) AND in_reply_to IS NOT NULL
+    UNION ALL
+    SELECT c.in_reply_to, a.depth + 1 FROM chirps c
+    JOIN ancestors a ON c.id = a.id
+    WHERE c.in_reply_to IS NOT NULL AND a.depth < sqlc.arg(max_depth)::int
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT chirps.id, 1 FROM chirps
+    WHERE in_reply_to = sqlc.arg(id)
+    UNION ALL
+    SELECT c.id, d.depth + 1 FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+    WHERE d.depth < sqlc.arg(max_depth)::int
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON chirps.id = descendants.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+ORDER BY chirps.created_at ASC, chirps.id ASC;
+
+-- name: ListTimeline :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+    AND (chirps.user_id = sqlc.arg(user_id) OR chirps.user_id IN (
+        SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id)
+    ))
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+        OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+    AND (rechirps.user_id = sqlc.arg(user_id) OR rechirps.user_id IN (
+        SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id)
+    ))
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+        OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+        OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+ORDER BY feed.activity_at DESC, feed.chirp_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListAuthorFeedAsc :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.user_id = sqlc.arg(author_id) AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE rechirps.user_id = sqlc.arg(author_id) AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+ORDER BY feed.activity_at ASC, feed.chirp_id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListAuthorFeedDesc :many
+SELECT feed.chirp_id, feed.rechirped_by, feed.activity_at FROM (
+    SELECT chirps.id AS chirp_id, NULL::uuid AS rechirped_by, chirps.created_at AS activity_at
+    FROM chirps
+    WHERE chirps.user_id = sqlc.arg(author_id) AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+    )
+    UNION ALL
+    SELECT rechirps.chirp_id, rechirps.user_id, rechirps.created_at
+    FROM rechirps
+    JOIN chirps ON chirps.id = rechirps.chirp_id
+    WHERE rechirps.user_id = sqlc.arg(author_id) AND chirps.deleted_at IS NULL AND (chirps.moderation_status = 'published' OR chirps.user_id = sqlc.narg(viewer_id)::uuid OR sqlc.arg(include_hidden)::bool)
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = rechirps.user_id AND users.deactivated_at IS NOT NULL
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+        OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = rechirps.user_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = rechirps.user_id
+    )
+) feed
+WHERE sqlc.narg(cursor_created_at)::timestamp IS NULL OR (feed.activity_at, feed.chirp_id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid)
+ORDER BY feed.activity_at DESC, feed.chirp_id DESC
+LIMIT sqlc.arg(page_limit);
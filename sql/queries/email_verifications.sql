@@ -0,0 +1,29 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+VALUES (sqlc.arg(token_hash), sqlc.arg(user_id), sqlc.arg(email), NOW(), NOW() + make_interval(secs => sqlc.arg(lifetime_seconds)::float8));
+
+-- name: HasRecentEmailVerificationToken :one
+SELECT EXISTS (
+    SELECT 1 FROM email_verification_tokens
+    WHERE user_id = sqlc.arg(user_id) AND created_at > NOW() - make_interval(secs => sqlc.arg(cooldown_seconds)::float8)
+);
+
+-- name: GetEmailVerificationToken :one
+SELECT * FROM email_verification_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW();
+
+-- name: GetEmailVerificationTokenForUpdate :one
+SELECT * FROM email_verification_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: GetPendingEmail :one
+SELECT email FROM email_verification_tokens
+WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: UseEmailVerificationTokens :exec
+UPDATE email_verification_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
@@ -0,0 +1,24 @@
+-- name: ExportChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: ExportLikes :many
+SELECT * FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportRechirps :many
+SELECT * FROM rechirps
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportFollows :many
+SELECT * FROM follows
+WHERE follower_id = $1 OR followee_id = $1
+ORDER BY created_at;
+
+-- name: ExportTokenFamilies :many
+SELECT * FROM token_families
+WHERE user_id = $1
+ORDER BY created_at;
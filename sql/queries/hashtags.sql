@@ -0,0 +1,53 @@
+-- name: CreateHashtags :exec
+INSERT INTO hashtags (id, tag, created_at)
+SELECT gen_random_uuid(), tag, now() FROM unnest(sqlc.arg(tags)::text[]) AS tag
+ON CONFLICT (tag) DO NOTHING;
+
+-- name: TagChirp :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+SELECT chirps.id, hashtags.id, chirps.created_at FROM chirps
+JOIN hashtags ON hashtags.tag = ANY(sqlc.arg(tags)::text[])
+WHERE chirps.id = sqlc.arg(chirp_id)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: ListChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg(tag) AND chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg(viewer_id)::uuid)
+    OR (blocks.blocker_id = sqlc.narg(viewer_id)::uuid AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::uuid AND mutes.muted_id = chirps.user_id
+)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListTrendingHashtags :many
+SELECT hashtags.tag, count(*) AS chirp_count,
+    sum(exp(-ln(2) * extract(epoch FROM now() - chirp_hashtags.created_at) / sqlc.arg(half_life_seconds)::float8))::float8 AS score
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at > now() - make_interval(secs => sqlc.arg(window_seconds)::float8)
+AND chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+GROUP BY hashtags.tag
+ORDER BY score DESC, hashtags.tag ASC
+LIMIT sqlc.arg(tag_limit);
@@ -0,0 +1,39 @@
+-- name: RecordLoginFailure :exec
+INSERT INTO login_failures (id, scope, subject, failed_at)
+VALUES (gen_random_uuid(), $1, $2, $3);
+
+-- name: CountLoginFailures :one
+SELECT COUNT(*) FROM login_failures
+WHERE scope = $1 AND subject = $2 AND failed_at > $3;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND subject = $2;
+
+-- name: PruneLoginFailures :exec
+DELETE FROM login_failures
+WHERE failed_at < $1;
+
+-- name: GetLoginLockout :one
+SELECT * FROM login_lockouts
+WHERE scope = $1 AND subject = $2;
+
+-- name: PutLoginLockout :one
+INSERT INTO login_lockouts (scope, subject, level, locked_until, created_at, updated_at)
+VALUES ($1, $2, $3, $4, NOW(), NOW())
+ON CONFLICT (scope, subject) DO UPDATE
+SET level = EXCLUDED.level, locked_until = EXCLUDED.locked_until, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteLoginLockout :exec
+DELETE FROM login_lockouts
+WHERE scope = $1 AND subject = $2;
+
+-- name: ListLoginLockouts :many
+SELECT * FROM login_lockouts
+WHERE locked_until > $1
+ORDER BY locked_until DESC;
+
+-- name: PruneLoginLockouts :exec
+DELETE FROM login_lockouts
+WHERE locked_until < $1;
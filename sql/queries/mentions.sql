@@ -0,0 +1,41 @@
+-- name: CreateMentions :exec
+INSERT INTO mentions (chirp_id, user_id, created_at)
+SELECT sqlc.arg(chirp_id)::uuid, id, now() FROM users
+WHERE lower(username) = ANY(sqlc.arg(usernames)::text[])
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = users.id
+    AND blocks.blocked_id = (SELECT user_id FROM chirps WHERE chirps.id = sqlc.arg(chirp_id)::uuid)
+)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: ListMentionsForChirps :many
+SELECT mentions.chirp_id, users.id AS user_id, COALESCE(users.username, '')::text AS username FROM mentions
+JOIN users ON users.id = mentions.user_id
+WHERE mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY mentions.chirp_id, users.username;
+
+-- name: ListMentionedChirps :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg(user_id) AND chirps.deleted_at IS NULL AND chirps.moderation_status = 'published'
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = chirps.user_id AND users.deactivated_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(user_id))
+    OR (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = chirps.user_id)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.arg(user_id) AND mutes.muted_id = chirps.user_id
+)
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,19 @@
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES (sqlc.arg(token_hash), sqlc.arg(user_id), NOW(), NOW() + make_interval(secs => sqlc.arg(lifetime_seconds)::float8));
+
+-- name: HasRecentPasswordResetToken :one
+SELECT EXISTS (
+    SELECT 1 FROM password_reset_tokens
+    WHERE user_id = sqlc.arg(user_id) AND created_at > NOW() - make_interval(secs => sqlc.arg(cooldown_seconds)::float8)
+);
+
+-- name: GetPasswordResetTokenForUpdate :one
+SELECT * FROM password_reset_tokens
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: UsePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
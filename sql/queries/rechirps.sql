@@ -0,0 +1,17 @@
+-- name: CreateRechirp :exec
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES ($1, $2, now())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteRechirp :exec
+DELETE FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountRechirps :many
+SELECT chirp_id, count(*) AS rechirp_count FROM rechirps
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetRechirpedChirpIDs :many
+SELECT chirp_id FROM rechirps
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
@@ -1,13 +1,72 @@
+-- name: CreateTokenFamily :one
+INSERT INTO token_families (id, user_id, created_at, user_agent, ip_address, last_used_at)
+VALUES (gen_random_uuid(), $1, NOW(), $2, $3, NOW())
+RETURNING *;
+
+-- name: TouchTokenFamily :exec
+UPDATE token_families
+SET user_agent = $2, ip_address = $3, last_used_at = NOW()
+WHERE id = $1;
+
+-- name: GetTokenFamily :one
+SELECT * FROM token_families
+WHERE id = $1;
+
+-- name: ListActiveTokenFamilies :many
+SELECT * FROM token_families
+WHERE token_families.user_id = $1 AND token_families.revoked_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE refresh_tokens.family_id = token_families.id
+    AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.rotated_at IS NULL AND refresh_tokens.expires_at > NOW()
+)
+ORDER BY token_families.last_used_at DESC;
+
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at)
-VALUES ($1, NOW(), NOW(), $2, NOW() + INTERVAL '60 days')
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, family_id, expires_at)
+VALUES ($1, NOW(), NOW(), $2, $3, NOW() + INTERVAL '60 days')
 RETURNING *;
 
--- name: GetUserFromRefreshToken :one
-SELECT user_id FROM refresh_tokens
-WHERE token = $1 AND expires_at > NOW() AND revoked_at IS NULL;
+-- name: GetRefreshTokenForUpdate :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1 AND expires_at > NOW()
+FOR UPDATE;
+
+-- name: RotateRefreshToken :exec
+UPDATE refresh_tokens
+SET rotated_at = NOW(), updated_at = NOW()
+WHERE token_hash = $1;
+
+-- name: RevokeTokenFamily :exec
+WITH family AS (
+    UPDATE token_families
+    SET revoked_at = COALESCE(token_families.revoked_at, NOW())
+    WHERE token_families.id = sqlc.arg(family_id)::uuid
+)
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE refresh_tokens.family_id = sqlc.arg(family_id)::uuid AND refresh_tokens.revoked_at IS NULL;
 
--- name: RevokeRefreshToken :exec
+-- name: RevokeUserRefreshTokens :exec
+WITH families AS (
+    UPDATE token_families
+    SET revoked_at = NOW()
+    WHERE token_families.user_id = sqlc.arg(user_id)::uuid AND token_families.revoked_at IS NULL
+)
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE refresh_tokens.user_id = sqlc.arg(user_id)::uuid AND refresh_tokens.revoked_at IS NULL;
+
+-- name: RevokeOtherTokenFamilies :exec
+WITH families AS (
+    UPDATE token_families
+    SET revoked_at = NOW()
+    WHERE token_families.user_id = sqlc.arg(user_id)::uuid AND token_families.id <> sqlc.arg(keep_id)::uuid AND token_families.revoked_at IS NULL
+)
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE refresh_tokens.user_id = sqlc.arg(user_id)::uuid AND refresh_tokens.family_id <> sqlc.arg(keep_id)::uuid AND refresh_tokens.revoked_at IS NULL;
+
+-- name: IsTokenFamilyRevoked :one
+SELECT revoked_at IS NOT NULL AS revoked FROM token_families
+WHERE id = $1;
@@ -0,0 +1,24 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, chirp_id, reporter_id, reason, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, now())
+RETURNING *;
+
+-- name: ListModerationQueue :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (moderation_status = 'held' OR EXISTS (
+    SELECT 1 FROM reports WHERE reports.chirp_id = chirps.id AND reports.status = 'pending'
+))
+AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, id) > (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListPendingReports :many
+SELECT * FROM reports
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]) AND status = 'pending'
+ORDER BY created_at ASC;
+
+-- name: ResolveReports :exec
+UPDATE reports
+SET status = $2, resolved_at = now(), resolved_by = $3
+WHERE chirp_id = $1 AND status = 'pending';
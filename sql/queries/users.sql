@@ -1,26 +1,102 @@
 -- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, password)
 VALUES (gen_random_uuid(), now(), now(), $1, $2)
-RETURNING id, created_at, updated_at, email, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website;
 
 -- name: ClearUsers :exec
 DELETE FROM users;
 
 -- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website FROM users
 WHERE email = $1;
 
 -- name: GetUserPassword :one
 SELECT password FROM users
 WHERE email = $1;
 
--- name: UpdateUser :one
+-- name: PatchUser :one
 UPDATE users
-SET email = $2, password = $3, updated_at = now()
-WHERE id = $1
-RETURNING id, created_at, updated_at, email, is_chirpy_red;
+SET password = COALESCE(sqlc.narg(password), password),
+    username = COALESCE(sqlc.narg(username), username),
+    display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url),
+    location = COALESCE(sqlc.narg(location), location),
+    website = COALESCE(sqlc.narg(website), website),
+    token_version = token_version + CASE WHEN sqlc.narg(password)::text IS NULL THEN 0 ELSE 1 END,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website;
 
 -- name: UpdateToChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = true
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website FROM users
+WHERE id = $1;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, now()), token_version = token_version + 1, updated_at = now()
+WHERE id = $1;
+
+-- name: UnsuspendUser :exec
+UPDATE users
+SET suspended_at = NULL, updated_at = now()
+WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, token_version = token_version + 1, updated_at = now()
+WHERE id = $1;
+
+-- name: PromoteFirstAdmin :one
+UPDATE users
+SET role = 'admin', updated_at = now()
+WHERE email = $1 AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin')
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website;
+
+-- name: GetUserTokenVersion :one
+SELECT token_version FROM users
+WHERE id = $1;
+
+-- name: SetUserPassword :one
+UPDATE users
+SET password = $2, token_version = token_version + 1, updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET email = $2, email_verified_at = now(), updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, is_chirpy_red, username, suspended_at, role, token_version, email_verified_at, display_name, bio, avatar_url, location, website;
+
+-- name: GetUserIDByUsername :one
+SELECT id FROM users
+WHERE lower(username) = lower($1);
+
+-- name: GetUserProfile :one
+SELECT users.id, users.created_at, users.username, users.display_name, users.bio, users.avatar_url, users.location, users.website, users.is_chirpy_red,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL AND chirps.moderation_status = 'published') AS chirp_count
+FROM users
+WHERE users.id = $1 AND users.deactivated_at IS NULL;
+
+-- name: DeactivateUser :one
+UPDATE users
+SET deactivated_at = now(), delete_after = now() + make_interval(secs => sqlc.arg(grace_seconds)::float8), token_version = token_version + 1, updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING delete_after;
+
+-- name: ReactivateUser :exec
+UPDATE users
+SET deactivated_at = NULL, delete_after = NULL, updated_at = now()
+WHERE id = $1 AND deactivated_at IS NOT NULL;
+
+-- name: DeleteExpiredUsers :execrows
+DELETE FROM users
+WHERE delete_after <= now();
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN username TEXT;
+
+CREATE UNIQUE INDEX users_username_lower_idx ON users (lower(username));
+
+CREATE TABLE mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX mentions_user_id_created_at_idx ON mentions (user_id, created_at);
+
+-- +goose Down
+DROP TABLE mentions;
+DROP INDEX users_username_lower_idx;
+ALTER TABLE users DROP COLUMN username;
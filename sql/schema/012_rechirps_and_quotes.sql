@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN quote_of UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of);
+
+CREATE TABLE rechirps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, chirp_id)
+);
+
+CREATE INDEX rechirps_chirp_id_idx ON rechirps (chirp_id);
+CREATE INDEX rechirps_user_id_created_at_idx ON rechirps (user_id, created_at);
+
+-- +goose Down
+DROP TABLE rechirps;
+DROP INDEX chirps_quote_of_idx;
+ALTER TABLE chirps DROP COLUMN quote_of;
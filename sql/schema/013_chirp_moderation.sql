@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN moderation_status TEXT NOT NULL DEFAULT 'published',
+    ADD COLUMN moderation_rules TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE chirps ADD CONSTRAINT chirps_moderation_status_check
+    CHECK (moderation_status IN ('published', 'held'));
+
+-- +goose Down
+ALTER TABLE chirps DROP CONSTRAINT chirps_moderation_status_check;
+ALTER TABLE chirps DROP COLUMN moderation_rules;
+ALTER TABLE chirps DROP COLUMN moderation_status;
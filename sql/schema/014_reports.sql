@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE chirps DROP CONSTRAINT chirps_moderation_status_check;
+ALTER TABLE chirps ADD CONSTRAINT chirps_moderation_status_check
+    CHECK (moderation_status IN ('published', 'held', 'hidden'));
+
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (status IN ('pending', 'dismissed', 'hidden', 'deleted')),
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE INDEX reports_pending_idx ON reports (chirp_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE reports;
+ALTER TABLE users DROP COLUMN suspended_at;
+UPDATE chirps SET moderation_status = 'held' WHERE moderation_status = 'hidden';
+ALTER TABLE chirps DROP CONSTRAINT chirps_moderation_status_check;
+ALTER TABLE chirps ADD CONSTRAINT chirps_moderation_status_check
+    CHECK (moderation_status IN ('published', 'held'));
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE token_families (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX token_families_user_id_idx ON token_families (user_id);
+
+-- Tokens are stored as hex SHA-256 hashes; existing tokens are hashed in
+-- place so that signed-in users stay signed in.
+UPDATE refresh_tokens SET token = encode(sha256(token::bytea), 'hex');
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN rotated_at TIMESTAMP;
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+INSERT INTO token_families (id, user_id, created_at, revoked_at)
+SELECT family_id, user_id, created_at, revoked_at FROM refresh_tokens;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+ALTER TABLE refresh_tokens ADD CONSTRAINT refresh_tokens_family_id_fkey
+    FOREIGN KEY (family_id) REFERENCES token_families(id) ON DELETE CASCADE;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+-- Hashed tokens can't be turned back into raw ones, so everyone is signed out.
+DELETE FROM refresh_tokens;
+DROP INDEX refresh_tokens_family_id_idx;
+ALTER TABLE refresh_tokens DROP COLUMN rotated_at;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
+DROP TABLE token_families;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE token_families
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_used_at TIMESTAMP;
+
+UPDATE token_families SET last_used_at = created_at;
+ALTER TABLE token_families ALTER COLUMN last_used_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE token_families DROP COLUMN last_used_at;
+ALTER TABLE token_families DROP COLUMN ip_address;
+ALTER TABLE token_families DROP COLUMN user_agent;
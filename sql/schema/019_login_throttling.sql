@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE login_failures (
+    id UUID PRIMARY KEY,
+    scope TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX login_failures_key_idx ON login_failures (scope, subject, failed_at);
+
+CREATE TABLE login_lockouts (
+    scope TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    level INTEGER NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, subject),
+    CHECK (scope IN ('account', 'ip'))
+);
+
+-- +goose Down
+DROP TABLE login_lockouts;
+DROP TABLE login_failures;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Failure and lockout times are written and compared from Go, which sends
+-- them with a UTC offset. TIMESTAMP drops the offset, shifting them by the
+-- server's zone; TIMESTAMPTZ keeps the instant.
+ALTER TABLE login_failures ALTER COLUMN failed_at TYPE TIMESTAMPTZ USING failed_at AT TIME ZONE 'UTC';
+ALTER TABLE login_lockouts ALTER COLUMN locked_until TYPE TIMESTAMPTZ USING locked_until AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE login_lockouts ALTER COLUMN locked_until TYPE TIMESTAMP USING locked_until AT TIME ZONE 'UTC';
+ALTER TABLE login_failures ALTER COLUMN failed_at TYPE TIMESTAMP USING failed_at AT TIME ZONE 'UTC';
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE used_mfa_challenges (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE used_mfa_challenges;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Password reset requests are throttled per IP alongside logins.
+ALTER TABLE login_lockouts DROP CONSTRAINT login_lockouts_scope_check;
+ALTER TABLE login_lockouts ADD CONSTRAINT login_lockouts_scope_check CHECK (scope IN ('account', 'ip', 'reset_ip'));
+
+-- +goose Down
+DELETE FROM login_lockouts WHERE scope = 'reset_ip';
+DELETE FROM login_failures WHERE scope = 'reset_ip';
+ALTER TABLE login_lockouts DROP CONSTRAINT login_lockouts_scope_check;
+ALTER TABLE login_lockouts ADD CONSTRAINT login_lockouts_scope_check CHECK (scope IN ('account', 'ip'));
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	trendingTagLimit      = 20
+)
+
+type trendingTag struct {
+	Tag        string  `json:"tag"`
+	ChirpCount int64   `json:"chirp_count"`
+	Score      float64 `json:"score"`
+}
+
+func (cfg *apiConfig) handlerGetTagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, 400, err.Error())
+		return
+	}
+	viewerID := auth.UserIDFromContext(r.Context())
+	rawChirps, err := cfg.dbQueries.ListChirpsByHashtag(r.Context(), database.ListChirpsByHashtagParams{
+		Tag:             tag,
+		ViewerID:        nullViewerID(viewerID),
+		CursorCreatedAt: page.nullCreatedAt(),
+		CursorID:        page.nullID(),
+		PageLimit:       page.fetchLimit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get chirps")
+		return
+	}
+	rawChirps = trimPage(w, r, page, rawChirps, chirpPosition)
+	chirps, err := cfg.buildChirps(r.Context(), rawChirps, viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build chirps")
+		return
+	}
+	respondWithJSON(w, 200, chirps)
+}
+
+// handlerGetTrendingTags ranks the tags used within the window (24h by
+// default, overridable with ?window=). Each use is weighted by an
+// exponential decay with a half-life of a quarter of the window, so a burst
+// of recent chirps outranks the same number spread across the whole window.
+func (cfg *apiConfig) handlerGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if rawWindow := r.URL.Query().Get("window"); rawWindow != "" {
+		parsed, err := time.ParseDuration(rawWindow)
+		if err != nil || parsed < time.Hour || parsed > maxTrendingWindow {
+			respondWithError(w, 400, "Invalid window")
+			return
+		}
+		window = parsed
+	}
+	rows, err := cfg.dbQueries.ListTrendingHashtags(r.Context(), database.ListTrendingHashtagsParams{
+		HalfLifeSeconds: (window / 4).Seconds(),
+		WindowSeconds:   window.Seconds(),
+		TagLimit:        trendingTagLimit,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get trending tags")
+		return
+	}
+	tags := []trendingTag{}
+	for _, row := range rows {
+		tags = append(tags, trendingTag{
+			Tag:        row.Tag,
+			ChirpCount: row.ChirpCount,
+			Score:      row.Score,
+		})
+	}
+	respondWithJSON(w, 200, tags)
+}
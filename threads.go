@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth = 10
+	maxThreadDepth     = 50
+)
+
+type threadNode struct {
+	chirp
+	Replies []*threadNode `json:"replies"`
+}
+
+type thread struct {
+	Ancestors []chirp     `json:"ancestors"`
+	Chirp     *threadNode `json:"chirp"`
+}
+
+func (cfg *apiConfig) handlerGetThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	depth := defaultThreadDepth
+	if rawDepth := r.URL.Query().Get("depth"); rawDepth != "" {
+		depth, err = strconv.Atoi(rawDepth)
+		if err != nil || depth < 1 || depth > maxThreadDepth {
+			respondWithError(w, 400, "Invalid depth")
+			return
+		}
+	}
+	viewerID := auth.UserIDFromContext(r.Context())
+	seeHidden := hasPermission(r, auth.PermViewHiddenChirps)
+	rawChirp, err := cfg.visibleChirp(r.Context(), chirpID, viewerID, seeHidden)
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	rawAncestors, err := cfg.dbQueries.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ID:       chirpID,
+		MaxDepth: int32(depth),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get thread")
+		return
+	}
+	rawDescendants, err := cfg.dbQueries.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ID:       chirpID,
+		MaxDepth: int32(depth),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get thread")
+		return
+	}
+	filter, err := cfg.chirpFilterFor(r.Context(), viewerID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get thread")
+		return
+	}
+	// Muting an author doesn't cut their posts out of the middle of a
+	// reply chain; only blocks do.
+	ancestors, err := cfg.assembleChirps(r.Context(), filter.seeable(visibleChirps(rawAncestors, viewerID, seeHidden)), filter)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build thread")
+		return
+	}
+	root, err := cfg.assembleChirps(r.Context(), []database.Chirp{rawChirp}, filter)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build thread")
+		return
+	}
+	// Replies by blocked and muted users are dropped here, and with them
+	// any replies nested under theirs.
+	descendants, err := cfg.assembleChirps(r.Context(), filter.listed(visibleChirps(rawDescendants, viewerID, seeHidden)), filter)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't build thread")
+		return
+	}
+	respondWithJSON(w, 200, thread{
+		Ancestors: ancestors,
+		Chirp:     buildReplyTree(append(root, descendants...)),
+	})
+}
+
+// buildReplyTree nests chirps under the chirp they reply to. The first chirp
+// is the root; the rest must all descend from it.
+func buildReplyTree(chirps []chirp) *threadNode {
+	nodes := map[uuid.UUID]*threadNode{}
+	for _, c := range chirps {
+		nodes[c.ID] = &threadNode{chirp: c, Replies: []*threadNode{}}
+	}
+	root := nodes[chirps[0].ID]
+	for _, c := range chirps[1:] {
+		if parent, ok := nodes[c.InReplyTo.UUID]; ok {
+			parent.Replies = append(parent.Replies, nodes[c.ID])
+		}
+	}
+	return root
+}
@@ -1,23 +1,73 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	auth "github.com/ecmoser/Chirpy_HTTP/internal/auth"
+	"github.com/ecmoser/Chirpy_HTTP/internal/chirptext"
 	"github.com/ecmoser/Chirpy_HTTP/internal/database"
+	"github.com/ecmoser/Chirpy_HTTP/internal/mailer"
+	"github.com/ecmoser/Chirpy_HTTP/internal/throttle"
 	"github.com/google/uuid"
 )
 
-type user struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	AccessToken  string    `json:"token"`
-	RefreshToken string    `json:"refresh_token"`
+// account is a user's own view of their account, including the private
+// fields that other users never see. The public view is profile.
+type account struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	PendingEmail  string    `json:"pending_email,omitempty"`
+	IsChirpyRed   bool      `json:"is_chirpy_red"`
+	Username      string    `json:"username,omitempty"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	AvatarURL     string    `json:"avatar_url"`
+	Location      string    `json:"location"`
+	Website       string    `json:"website"`
+	Role          string    `json:"role"`
+	MFAEnabled    bool      `json:"mfa_enabled"`
+}
+
+// loginResponse is an account along with the tokens for a new session.
+type loginResponse struct {
+	account
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// accountFromDB builds an account from a users row. Handlers that change a
+// user load the account again with accountFor instead of converting their
+// own query's row type, so there's only one mapping to keep in step with
+// the users columns.
+func accountFromDB(dbUser database.GetUserByIDRow) account {
+	return account{
+		ID:            dbUser.ID,
+		CreatedAt:     dbUser.CreatedAt,
+		UpdatedAt:     dbUser.UpdatedAt,
+		Email:         dbUser.Email,
+		EmailVerified: dbUser.EmailVerifiedAt.Valid,
+		IsChirpyRed:   dbUser.IsChirpyRed,
+		Username:      dbUser.Username.String,
+		DisplayName:   dbUser.DisplayName,
+		Bio:           dbUser.Bio,
+		AvatarURL:     dbUser.AvatarUrl,
+		Location:      dbUser.Location,
+		Website:       dbUser.Website,
+		Role:          dbUser.Role,
+	}
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
@@ -33,29 +83,39 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 400, "Error decoding request body")
 		return
 	}
+	email, err := mailer.NormalizeAddress(rBody.Email)
+	if err != nil {
+		respondWithError(w, 400, "Invalid email address")
+		return
+	}
 	hashed, err := auth.HashPassword(rBody.Password)
 	if err != nil {
 		respondWithError(w, 400, "Error hashing password")
 		return
 	}
 	dbUser, err := cfg.dbQueries.CreateUser(r.Context(), database.CreateUserParams{
-		Email:    rBody.Email,
+		Email:    email,
 		Password: hashed,
 	})
-	u := user{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+	if isUniqueViolation(err, "users_email_key") {
+		respondWithError(w, 409, "Email already registered")
+		return
 	}
 	if err != nil {
 		respondWithError(w, 500, "Error creating user")
 		return
 	}
-	respondWithJSON(w, 201, u)
+	acct, err := cfg.accountFor(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Error creating user")
+		return
+	}
+	cfg.queueEmailVerification(r, dbUser.ID, dbUser.Email)
+	respondWithJSON(w, 201, acct)
 }
 
+// handlerReset wipes all users. Besides the permission check, it only ever
+// runs on the dev platform.
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	if cfg.platform != "dev" {
 		respondWithError(w, 403, "Forbidden")
@@ -85,72 +145,208 @@ func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 400, "Error decoding request body")
 		return
 	}
-	userPassword, err := cfg.dbQueries.GetUserPassword(r.Context(), rBody.Email)
-	if err != nil || auth.CheckPasswordHash(userPassword, rBody.Password) != nil {
+	email, err := mailer.NormalizeAddress(rBody.Email)
+	if err != nil {
 		respondWithError(w, 401, "Invalid email or password")
 		return
 	}
-	dbUser, err := cfg.dbQueries.GetUserByEmail(r.Context(), rBody.Email)
+	throttleKeys := []throttle.Key{throttle.AccountKey(email), throttle.IPKey(cfg.clientIP(r))}
+	wait, err := cfg.loginLimiter.Check(r.Context(), throttleKeys...)
 	if err != nil {
+		respondWithError(w, 500, "Error checking login attempts")
+		return
+	}
+	if wait > 0 {
+		respondTooManyLogins(w, wait)
+		return
+	}
+	userPassword, err := cfg.dbQueries.GetUserPassword(r.Context(), email)
+	if err != nil || auth.CheckPasswordHash(userPassword, rBody.Password) != nil {
+		err = cfg.loginLimiter.Fail(r.Context(), throttleKeys...)
+		if err != nil {
+			log.Printf("Error recording failed login: %v", err)
+		}
 		respondWithError(w, 401, "Invalid email or password")
 		return
 	}
-	token, err := auth.MakeJWT(dbUser.ID, cfg.tokenSecret, time.Duration(1)*time.Hour)
+	dbUser, err := cfg.dbQueries.GetUserByEmail(r.Context(), email)
 	if err != nil {
-		respondWithError(w, 500, "Error creating JWT")
+		respondWithError(w, 401, "Invalid email or password")
 		return
 	}
-	refreshToken, err := auth.MakeRefreshToken()
+	if dbUser.SuspendedAt.Valid {
+		respondWithError(w, 403, "Account suspended")
+		return
+	}
+	mfaEnabled, err := cfg.mfaEnabled(r.Context(), dbUser.ID)
 	if err != nil {
-		respondWithError(w, 500, "Error creating refresh token")
+		respondWithError(w, 500, "Error checking two-factor authentication")
 		return
 	}
-	_, err = cfg.dbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:  refreshToken,
-		UserID: dbUser.ID,
-	})
+	if mfaEnabled {
+		// Failed logins are only forgotten once the second factor is
+		// verified, so a known password doesn't reset the code guessing
+		// budget.
+		challenge, err := auth.MakeMFAChallenge(dbUser.ID, cfg.jwtKeys, mfaChallengeLifetime)
+		if err != nil {
+			respondWithError(w, 500, "Error creating MFA challenge")
+			return
+		}
+		respondWithJSON(w, 200, mfaChallenge{MFARequired: true, MFAToken: challenge})
+		return
+	}
+	err = cfg.loginLimiter.Succeed(r.Context(), throttleKeys[0])
+	if err != nil {
+		log.Printf("Error clearing failed logins: %v", err)
+	}
+	cfg.respondWithNewSession(w, r, dbUser.ID, dbUser.Role, dbUser.TokenVersion)
+}
+
+// respondWithNewSession completes a login: it starts a session and responds
+// with the account and the session's tokens.
+func (cfg *apiConfig) respondWithNewSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID, role string, tokenVersion int32) {
+	token, refreshToken, err := cfg.startSession(r, userID, role, tokenVersion)
 	if err != nil {
 		respondWithError(w, 500, "Error saving refresh token")
 		return
 	}
-	u := user{
-		ID:           dbUser.ID,
-		CreatedAt:    dbUser.CreatedAt,
-		UpdatedAt:    dbUser.UpdatedAt,
-		Email:        dbUser.Email,
-		IsChirpyRed:  dbUser.IsChirpyRed,
+	acct, err := cfg.accountFor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get account")
+		return
+	}
+	respondWithJSON(w, 200, loginResponse{
+		account:      acct,
 		AccessToken:  token,
 		RefreshToken: refreshToken,
+	})
+}
+
+// startSession starts a new login session for the user and returns its
+// first access and refresh tokens.
+func (cfg *apiConfig) startSession(r *http.Request, userID uuid.UUID, role string, tokenVersion int32) (string, string, error) {
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	family, err := qtx.CreateTokenFamily(r.Context(), database.CreateTokenFamilyParams{
+		UserID:    userID,
+		UserAgent: userAgent(r),
+		IpAddress: cfg.clientIP(r),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := issueRefreshToken(r.Context(), qtx, userID, family.ID)
+	if err != nil {
+		return "", "", err
+	}
+	// Logging in during the grace period after deleting an account is how
+	// the account is recovered.
+	err = qtx.ReactivateUser(r.Context(), userID)
+	if err != nil {
+		return "", "", err
 	}
-	respondWithJSON(w, 200, u)
+	err = tx.Commit()
+	if err != nil {
+		return "", "", err
+	}
+	token, err := auth.MakeJWT(userID, auth.Role(role), cfg.jwtKeys, time.Duration(1)*time.Hour, auth.WithSession(family.ID), auth.WithVersion(tokenVersion))
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
 }
 
+// handlerRefresh trades a refresh token for a new access token and a new
+// refresh token. Each refresh token works once: presenting one that was
+// already rotated means it was copied, so the whole family of tokens
+// descended from the same login is revoked.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	response := struct {
-		Token string `json:"token"`
-	}{
-		Token: "",
-	}
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}{}
 	rHeader := r.Header
 	token, err := auth.GetBearerToken(rHeader)
 	if err != nil {
 		respondWithError(w, 401, "No token found in header")
 		return
 	}
-	userID, err := cfg.dbQueries.GetUserFromRefreshToken(r.Context(), token)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Error refreshing token")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	stored, err := qtx.GetRefreshTokenForUpdate(r.Context(), auth.HashRefreshToken(token))
+	if err != nil {
+		respondWithError(w, 401, "Invalid refresh token")
+		return
+	}
+	if stored.RotatedAt.Valid {
+		err = qtx.RevokeTokenFamily(r.Context(), stored.FamilyID)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			respondWithError(w, 500, "Error revoking refresh tokens")
+			return
+		}
+		cfg.revokedSessions.Invalidate(stored.FamilyID)
+		respondWithError(w, 401, "Invalid refresh token")
+		return
+	}
+	if stored.RevokedAt.Valid {
+		respondWithError(w, 401, "Invalid refresh token")
+		return
+	}
+	dbUser, err := qtx.GetUserByID(r.Context(), stored.UserID)
 	if err != nil {
 		respondWithError(w, 401, "Invalid refresh token")
 		return
 	}
-	accessToken, err := auth.MakeJWT(userID, cfg.tokenSecret, time.Duration(1)*time.Hour)
+	if dbUser.SuspendedAt.Valid {
+		respondWithError(w, 403, "Account suspended")
+		return
+	}
+	err = qtx.RotateRefreshToken(r.Context(), stored.TokenHash)
+	if err != nil {
+		respondWithError(w, 500, "Error refreshing token")
+		return
+	}
+	err = qtx.TouchTokenFamily(r.Context(), database.TouchTokenFamilyParams{
+		ID:        stored.FamilyID,
+		UserAgent: userAgent(r),
+		IpAddress: cfg.clientIP(r),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Error refreshing token")
+		return
+	}
+	response.RefreshToken, err = issueRefreshToken(r.Context(), qtx, stored.UserID, stored.FamilyID)
+	if err != nil {
+		respondWithError(w, 500, "Error refreshing token")
+		return
+	}
+	err = tx.Commit()
+	if err != nil {
+		respondWithError(w, 500, "Error refreshing token")
+		return
+	}
+	response.Token, err = auth.MakeJWT(stored.UserID, auth.Role(dbUser.Role), cfg.jwtKeys, time.Duration(1)*time.Hour, auth.WithSession(stored.FamilyID), auth.WithVersion(dbUser.TokenVersion))
 	if err != nil {
 		respondWithError(w, 500, "Error creating access token")
 		return
 	}
-	response.Token = accessToken
 	respondWithJSON(w, 200, response)
 }
 
+// handlerRevokeRefreshToken logs out the session the refresh token belongs
+// to by revoking its whole family.
 func (cfg *apiConfig) handlerRevokeRefreshToken(w http.ResponseWriter, r *http.Request) {
 	rHeader := r.Header
 	token, err := auth.GetBearerToken(rHeader)
@@ -158,63 +354,323 @@ func (cfg *apiConfig) handlerRevokeRefreshToken(w http.ResponseWriter, r *http.R
 		respondWithError(w, 401, "No token found in header")
 		return
 	}
-	err = cfg.dbQueries.RevokeRefreshToken(r.Context(), token)
+	stored, err := cfg.dbQueries.GetRefreshTokenForUpdate(r.Context(), auth.HashRefreshToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(204)
+		return
+	}
 	if err != nil {
 		respondWithError(w, 500, "Error revoking refresh token")
 		return
 	}
+	err = cfg.dbQueries.RevokeTokenFamily(r.Context(), stored.FamilyID)
+	if err != nil {
+		respondWithError(w, 500, "Error revoking refresh token")
+		return
+	}
+	cfg.revokedSessions.Invalidate(stored.FamilyID)
 	w.WriteHeader(204)
 }
 
-func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
-	type requestBody struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+// issueRefreshToken mints a refresh token in the given family and stores its
+// hash. The raw token is only ever returned to the client.
+func issueRefreshToken(ctx context.Context, qtx *database.Queries, userID, familyID uuid.UUID) (string, error) {
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
 	}
-	header := r.Header
-	token, err := auth.GetBearerToken(header)
+	_, err = qtx.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		TokenHash: auth.HashRefreshToken(refreshToken),
+		UserID:    userID,
+		FamilyID:  familyID,
+	})
 	if err != nil {
-		respondWithError(w, 401, "No token found in header")
-		return
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 50
+	maxProfileURLLength  = 2048
+)
+
+// validProfileURL reports whether s can be shown as a link on a profile:
+// empty, or an absolute http or https URL.
+func validProfileURL(s string) bool {
+	if s == "" {
+		return true
+	}
+	if len(s) > maxProfileURLLength {
+		return false
 	}
-	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// accountFor loads the caller's account, along with the email address
+// waiting for verification, if any.
+func (cfg *apiConfig) accountFor(ctx context.Context, userID uuid.UUID) (account, error) {
+	dbUser, err := cfg.dbQueries.GetUserByID(ctx, userID)
+	if err != nil {
+		return account{}, err
+	}
+	pendingEmail, err := cfg.dbQueries.GetPendingEmail(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return account{}, err
+	}
+	mfaEnabled, err := cfg.mfaEnabled(ctx, userID)
 	if err != nil {
-		respondWithError(w, 401, "Invalid token")
+		return account{}, err
+	}
+	acct := accountFromDB(dbUser)
+	acct.PendingEmail = pendingEmail
+	acct.MFAEnabled = mfaEnabled
+	return acct, nil
+}
+
+func (cfg *apiConfig) handlerGetMe(w http.ResponseWriter, r *http.Request) {
+	acct, err := cfg.accountFor(r.Context(), auth.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, 500, "Couldn't get account")
 		return
 	}
+	respondWithJSON(w, 200, acct)
+}
+
+// handlerReplaceUser serves PUT /api/users, which predates PATCH
+// /api/users/me. The body carries the email and password (and optionally a
+// username); it goes through the same path as PATCH, so the current
+// password is required and checked against the login limiter.
+func (cfg *apiConfig) handlerReplaceUser(w http.ResponseWriter, r *http.Request) {
+	type requestBody struct {
+		Email           string `json:"email"`
+		Password        string `json:"password"`
+		CurrentPassword string `json:"current_password"`
+		Username        string `json:"username"`
+	}
 	defer r.Body.Close()
 	decoder := json.NewDecoder(r.Body)
 	rBody := requestBody{}
-	err = decoder.Decode(&rBody)
+	err := decoder.Decode(&rBody)
 	if err != nil {
 		respondWithError(w, 400, "Error decoding request body")
 		return
 	}
-	hashed, err := auth.HashPassword(rBody.Password)
+	if rBody.Password == "" {
+		respondWithError(w, 400, "Password is required")
+		return
+	}
+	update := userUpdate{
+		Email:           &rBody.Email,
+		Password:        &rBody.Password,
+		CurrentPassword: rBody.CurrentPassword,
+	}
+	if rBody.Username != "" {
+		update.Username = &rBody.Username
+	}
+	cfg.updateUser(w, r, update)
+}
+
+// userUpdate is a partial update to the caller's account; nil fields are
+// left unchanged.
+type userUpdate struct {
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"current_password"`
+	Username        *string `json:"username"`
+	DisplayName     *string `json:"display_name"`
+	Bio             *string `json:"bio"`
+	AvatarURL       *string `json:"avatar_url"`
+	Location        *string `json:"location"`
+	Website         *string `json:"website"`
+}
+
+// handlerUpdateUser applies a partial update: fields left out of the body
+// are unchanged. Changing the email or password needs the current password
+// as well as the access token. A new email only takes effect once the link
+// sent to it is followed. A new password bumps the token version, revoking
+// every access token issued so far, and signs out every other session; the
+// response carries a fresh access token for this one.
+func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	decoder := json.NewDecoder(r.Body)
+	rBody := userUpdate{}
+	err := decoder.Decode(&rBody)
 	if err != nil {
-		respondWithError(w, 400, "Error hashing password")
+		respondWithError(w, 400, "Error decoding request body")
 		return
 	}
-	dbUser, err := cfg.dbQueries.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:       userID,
-		Email:    rBody.Email,
-		Password: hashed,
-	})
+	cfg.updateUser(w, r, rBody)
+}
+
+// updateUser validates and applies rBody to the caller's account, for both
+// PATCH /api/users/me and PUT /api/users.
+func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request, rBody userUpdate) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if rBody.Username != nil && !chirptext.ValidUsername(*rBody.Username) {
+		respondWithError(w, 400, "Username must be 3-15 letters, digits or underscores")
+		return
+	}
+	if rBody.DisplayName != nil && utf8.RuneCountInString(*rBody.DisplayName) > maxDisplayNameLength {
+		respondWithError(w, 400, fmt.Sprintf("Display name must be at most %d characters", maxDisplayNameLength))
+		return
+	}
+	if rBody.Bio != nil && utf8.RuneCountInString(*rBody.Bio) > maxBioLength {
+		respondWithError(w, 400, fmt.Sprintf("Bio must be at most %d characters", maxBioLength))
+		return
+	}
+	if rBody.Location != nil && utf8.RuneCountInString(*rBody.Location) > maxLocationLength {
+		respondWithError(w, 400, fmt.Sprintf("Location must be at most %d characters", maxLocationLength))
+		return
+	}
+	if rBody.AvatarURL != nil && !validProfileURL(*rBody.AvatarURL) {
+		respondWithError(w, 400, "Avatar URL must be an http or https URL")
+		return
+	}
+	if rBody.Website != nil && !validProfileURL(*rBody.Website) {
+		respondWithError(w, 400, "Website must be an http or https URL")
+		return
+	}
+	if rBody.Password != nil && *rBody.Password == "" {
+		respondWithError(w, 400, "Password cannot be empty")
+		return
+	}
+	current, err := cfg.dbQueries.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Error updating user")
+		return
+	}
+	pendingEmail := ""
+	if rBody.Email != nil {
+		email, err := mailer.NormalizeAddress(*rBody.Email)
+		if err != nil {
+			respondWithError(w, 400, "Invalid email address")
+			return
+		}
+		if email != current.Email {
+			other, err := cfg.dbQueries.GetUserByEmail(r.Context(), email)
+			if err == nil && other.ID != principal.UserID {
+				respondWithError(w, 409, "Email already registered")
+				return
+			}
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, 500, "Error updating user")
+				return
+			}
+			pendingEmail = email
+		}
+	}
+	if pendingEmail != "" || rBody.Password != nil {
+		throttleKeys := []throttle.Key{throttle.AccountKey(current.Email), throttle.IPKey(cfg.clientIP(r))}
+		wait, err := cfg.loginLimiter.Check(r.Context(), throttleKeys...)
+		if err != nil {
+			respondWithError(w, 500, "Error checking login attempts")
+			return
+		}
+		if wait > 0 {
+			respondTooManyLogins(w, wait)
+			return
+		}
+		hash, err := cfg.dbQueries.GetUserPassword(r.Context(), current.Email)
+		if err != nil {
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+		if rBody.CurrentPassword == "" || auth.CheckPasswordHash(hash, rBody.CurrentPassword) != nil {
+			err = cfg.loginLimiter.Fail(r.Context(), throttleKeys...)
+			if err != nil {
+				log.Printf("Error recording failed password check: %v", err)
+			}
+			respondWithError(w, 403, "Current password is incorrect")
+			return
+		}
+	}
+	params := database.PatchUserParams{ID: principal.UserID}
+	if rBody.Password != nil {
+		hashed, err := auth.HashPassword(*rBody.Password)
+		if err != nil {
+			respondWithError(w, 400, "Error hashing password")
+			return
+		}
+		params.Password = sql.NullString{String: hashed, Valid: true}
+	}
+	if rBody.Username != nil {
+		params.Username = sql.NullString{String: *rBody.Username, Valid: true}
+	}
+	if rBody.DisplayName != nil {
+		params.DisplayName = sql.NullString{String: strings.TrimSpace(*rBody.DisplayName), Valid: true}
+	}
+	if rBody.Bio != nil {
+		params.Bio = sql.NullString{String: strings.TrimSpace(*rBody.Bio), Valid: true}
+	}
+	if rBody.AvatarURL != nil {
+		params.AvatarUrl = sql.NullString{String: *rBody.AvatarURL, Valid: true}
+	}
+	if rBody.Location != nil {
+		params.Location = sql.NullString{String: strings.TrimSpace(*rBody.Location), Valid: true}
+	}
+	if rBody.Website != nil {
+		params.Website = sql.NullString{String: *rBody.Website, Valid: true}
+	}
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, 500, "Error updating user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+	dbUser, err := qtx.PatchUser(r.Context(), params)
+	if isUniqueViolation(err, "users_username_lower_idx") {
+		respondWithError(w, 409, "Username already taken")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Error updating user")
+		return
+	}
+	if rBody.Password != nil {
+		err = qtx.RevokeOtherTokenFamilies(r.Context(), database.RevokeOtherTokenFamiliesParams{
+			UserID: principal.UserID,
+			KeepID: principal.SessionID,
+		})
+		if err != nil {
+			respondWithError(w, 500, "Error updating user")
+			return
+		}
+	}
+	err = tx.Commit()
 	if err != nil {
 		respondWithError(w, 500, "Error updating user")
 		return
 	}
+	token := ""
+	if rBody.Password != nil {
+		cfg.tokenVersions.Invalidate(principal.UserID)
+		token, err = auth.MakeJWT(dbUser.ID, auth.Role(dbUser.Role), cfg.jwtKeys, time.Duration(1)*time.Hour, auth.WithSession(principal.SessionID), auth.WithVersion(dbUser.TokenVersion))
+		if err != nil {
+			respondWithError(w, 500, "Error creating JWT")
+			return
+		}
+	}
+	if pendingEmail != "" {
+		cfg.queueEmailVerification(r, principal.UserID, pendingEmail)
+	}
+	acct, err := cfg.accountFor(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Error updating user")
+		return
+	}
+	if pendingEmail != "" {
+		acct.PendingEmail = pendingEmail
+	}
 	respondWithJSON(w, 200, struct {
-		ID          uuid.UUID `json:"id"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
-		Email       string    `json:"email"`
-		IsChirpyRed bool      `json:"is_chirpy_red"`
+		account
+		Token string `json:"token,omitempty"`
 	}{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+		account: acct,
+		Token:   token,
 	})
 }